@@ -0,0 +1,214 @@
+package gpgeez
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// Names of the files in an archive created by Archive.
+const (
+	archivePublicKey  = "pubkey.asc"
+	archiveSecretKey  = "seckey.asc"
+	archiveRevocation = "revoke.asc"
+	archiveReadme     = "README.txt"
+)
+
+// maxArchiveFileSize bounds the size of the files read by OpenArchive once
+// decompressed, so that a crafted archive can't exhaust memory.
+const maxArchiveFileSize = 1 << 20
+
+const archiveReadmeText = `This archive is a backup of the OpenPGP key %s.
+
+  pubkey.asc  the public key
+  seckey.asc  the private key, protected by a passphrase
+  revoke.asc  a revocation certificate for the key
+
+To restore the key:
+
+  gpg --import seckey.asc
+
+If the private key is lost or compromised, revoke the key by importing the
+revocation certificate and publishing the revoked key:
+
+  gpg --import revoke.asc
+  gpg --send-keys %s
+
+Anyone who has revoke.asc can revoke the key, so store this archive safely.
+`
+
+// Archive returns a ZIP archive to back up the key. It contains the public
+// key, the private key protected with passphrase, a revocation certificate
+// (see GenerateRevocationCertificate), and a README.txt explaining how to use
+// them.
+//
+// The private key is protected with config.Cipher(), which must be a variant
+// of AES, and config.Hash() for the S2K.
+func (key *Key) Archive(passphrase []byte, config *Config) ([]byte, error) {
+	if key == nil {
+		return nil, ErrNilKey
+	}
+	if len(passphrase) == 0 {
+		return nil, errors.New("gpgeez: a passphrase is required")
+	}
+	public, err := key.Armor()
+	if err != nil {
+		return nil, err
+	}
+	revocation, err := key.GenerateRevocationCertificate(RevocationReasonUnspecified, "", config)
+	if err != nil {
+		return nil, err
+	}
+	secret := new(bytes.Buffer)
+	w, err := config.armorEncode(secret, openpgp.PrivateKeyType, config.armorHeaders())
+	if err != nil {
+		return nil, err
+	}
+	err = key.serializeProtected(w, passphrase, config)
+	if err != nil {
+		return nil, err
+	}
+	w.Close()
+	fingerprint := key.Fingerprint()
+
+	buf := new(bytes.Buffer)
+	z := zip.NewWriter(buf)
+	files := []struct {
+		name     string
+		contents string
+	}{
+		{archivePublicKey, public},
+		{archiveSecretKey, secret.String()},
+		{archiveRevocation, revocation},
+		{archiveReadme, fmt.Sprintf(archiveReadmeText, fingerprint, fingerprint)},
+	}
+	for _, file := range files {
+		f, err := z.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: config.now()})
+		if err != nil {
+			return nil, err
+		}
+		_, err = f.Write([]byte(file.contents))
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = z.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// OpenArchive reads an archive created by Archive, and returns the key and the
+// armored revocation certificate. The private key material is still protected
+// by the passphrase: only the public part of the key can be used until
+// DecryptPrivateKey is called. Other files than the keys and the revocation
+// certificate, such as the README, are ignored.
+func OpenArchive(data []byte) (*Key, string, error) {
+	key, _, revocation, err := openArchive(data)
+	return key, revocation, err
+}
+
+// openArchive is like OpenArchive, but also returns the key read from
+// pubkey.asc.
+func openArchive(data []byte) (*Key, *Key, string, error) {
+	z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, "", err
+	}
+	files := make(map[string]string)
+	for _, f := range z.File {
+		switch f.Name {
+		case archivePublicKey, archiveSecretKey, archiveRevocation:
+		default:
+			continue
+		}
+		if _, ok := files[f.Name]; ok {
+			return nil, nil, "", errors.New("gpgeez: archive has more than one " + f.Name)
+		}
+		r, err := f.Open()
+		if err != nil {
+			return nil, nil, "", err
+		}
+		contents, err := ioutil.ReadAll(io.LimitReader(r, maxArchiveFileSize+1))
+		r.Close()
+		if err != nil {
+			return nil, nil, "", err
+		}
+		if len(contents) > maxArchiveFileSize {
+			return nil, nil, "", errors.New("gpgeez: " + f.Name + " is too large")
+		}
+		files[f.Name] = string(contents)
+	}
+	for _, name := range []string{archivePublicKey, archiveSecretKey, archiveRevocation} {
+		if _, ok := files[name]; !ok {
+			return nil, nil, "", errors.New("gpgeez: archive is missing " + name)
+		}
+	}
+
+	public, err := ImportPublicKey(files[archivePublicKey])
+	if err != nil {
+		return nil, nil, "", err
+	}
+	key, err := ImportPrivateKey(files[archiveSecretKey])
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if key.Fingerprint() != public.Fingerprint() {
+		return nil, nil, "", errors.New("gpgeez: pubkey.asc and seckey.asc are different keys")
+	}
+	return key, public, files[archiveRevocation], nil
+}
+
+// VerifyArchiveIntegrity checks that an archive created by Archive can be
+// used to recover the key: the private key is decrypted with passphrase, a
+// test message is signed with it and verified with the public key, and the
+// revocation certificate is checked. It is meant for disaster recovery
+// drills. The returned error describes the first failure.
+func VerifyArchiveIntegrity(archiveBytes []byte, passphrase []byte) error {
+	key, public, revocation, err := openArchive(archiveBytes)
+	if err != nil {
+		return fmt.Errorf("gpgeez: opening archive: %w", err)
+	}
+	err = key.DecryptPrivateKey(passphrase)
+	if err != nil {
+		return fmt.Errorf("gpgeez: decrypting private key: %w", err)
+	}
+
+	config := &Config{}
+	message := []byte("gpgeez archive integrity check " + key.Fingerprint())
+	sig, err := key.Sign(bytes.NewReader(message), config)
+	if err != nil {
+		return fmt.Errorf("gpgeez: signing test message: %w", err)
+	}
+	err = public.Verify(bytes.NewReader(message), sig, config)
+	if err != nil {
+		return fmt.Errorf("gpgeez: verifying test message: %w", err)
+	}
+
+	block, err := armor.Decode(strings.NewReader(revocation))
+	if err != nil {
+		return fmt.Errorf("gpgeez: reading revocation certificate: %w", err)
+	}
+	p, err := packet.Read(block.Body)
+	if err != nil {
+		return fmt.Errorf("gpgeez: reading revocation certificate: %w", err)
+	}
+	s, ok := p.(*packet.Signature)
+	if !ok || s.SigType != packet.SigTypeKeyRevocation {
+		return errors.New("gpgeez: revoke.asc is not a key revocation")
+	}
+	err = public.PrimaryKey.VerifyRevocationSignature(s)
+	if err != nil {
+		return fmt.Errorf("gpgeez: verifying revocation certificate: %w", err)
+	}
+	return nil
+}
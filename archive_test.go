@@ -0,0 +1,121 @@
+package gpgeez
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchive(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	_, err = key.Archive(nil, &config)
+	assert.NotNil(t, err, "Archive must require a passphrase")
+
+	data, err := key.Archive([]byte("correct horse"), &config)
+	assert.Nil(t, err, "Archive errored")
+	z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	assert.Nil(t, err, "invalid zip archive")
+	files := make(map[string]string)
+	for _, f := range z.File {
+		r, err := f.Open()
+		assert.Nil(t, err)
+		contents, err := ioutil.ReadAll(r)
+		assert.Nil(t, err)
+		files[f.Name] = string(contents)
+	}
+	assert.Equal(t, 4, len(files))
+
+	public, err := ImportPublicKey(files["pubkey.asc"])
+	assert.Nil(t, err, "invalid pubkey.asc")
+	assert.Equal(t, key.Fingerprint(), public.Fingerprint())
+
+	secret, err := ImportPrivateKey(files["seckey.asc"])
+	assert.Nil(t, err, "invalid seckey.asc")
+	assert.True(t, secret.PrivateKey.Encrypted)
+	assert.NotNil(t, secret.PrivateKey.Decrypt([]byte("wrong")))
+	assert.Nil(t, secret.PrivateKey.Decrypt([]byte("correct horse")))
+	for _, subkey := range secret.Subkeys {
+		assert.True(t, subkey.PrivateKey.Encrypted)
+		assert.Nil(t, subkey.PrivateKey.Decrypt([]byte("correct horse")))
+	}
+	sig, err := secret.Sign(strings.NewReader("hello"), &config)
+	assert.Nil(t, err, "Sign errored")
+	assert.Nil(t, key.Verify(strings.NewReader("hello"), sig, &config))
+
+	assert.Contains(t, files["revoke.asc"], "This is a revocation certificate")
+	assert.Contains(t, files["README.txt"], key.Fingerprint())
+}
+
+func TestOpenArchive(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	data, err := key.Archive([]byte("correct horse"), &config)
+	assert.Nil(t, err, "Archive errored")
+
+	restored, revocation, err := OpenArchive(data)
+	assert.Nil(t, err, "OpenArchive errored")
+	assert.Equal(t, key.Fingerprint(), restored.Fingerprint())
+	assert.Contains(t, revocation, "This is a revocation certificate")
+
+	_, err = restored.Sign(strings.NewReader("hello"), &config)
+	assert.NotNil(t, err, "private key must still be encrypted")
+	assert.NotNil(t, restored.DecryptPrivateKey([]byte("wrong")))
+	assert.Nil(t, restored.DecryptPrivateKey([]byte("correct horse")))
+	sig, err := restored.Sign(strings.NewReader("hello"), &config)
+	assert.Nil(t, err, "Sign errored")
+	assert.Nil(t, key.Verify(strings.NewReader("hello"), sig, &config))
+
+	_, _, err = OpenArchive([]byte("not a zip"))
+	assert.NotNil(t, err)
+
+	// Unknown files are skipped, however large they are.
+	big := strings.Repeat("x", maxArchiveFileSize+1)
+	_, _, err = OpenArchive(rewriteArchive(t, data, "photo.jpg", big))
+	assert.Nil(t, err, "OpenArchive must skip unknown files")
+	_, _, err = OpenArchive(rewriteArchive(t, data, "revoke.asc", big))
+	assert.NotNil(t, err, "OpenArchive must reject oversized files")
+}
+
+// rewriteArchive returns a copy of the archive in data where the file called
+// name has the given contents, replacing the existing one if any.
+func rewriteArchive(t *testing.T, data []byte, name, contents string) []byte {
+	z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	assert.Nil(t, err, "invalid zip archive")
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	for _, f := range z.File {
+		if f.Name == name {
+			continue
+		}
+		assert.Nil(t, w.Copy(f))
+	}
+	f, err := w.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+	assert.Nil(t, err)
+	_, err = f.Write([]byte(contents))
+	assert.Nil(t, err)
+	assert.Nil(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestVerifyArchiveIntegrity(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	data, err := key.Archive([]byte("correct horse"), &config)
+	assert.Nil(t, err, "Archive errored")
+
+	assert.Nil(t, VerifyArchiveIntegrity(data, []byte("correct horse")))
+	err = VerifyArchiveIntegrity(data, []byte("wrong"))
+	assert.True(t, strings.HasPrefix(err.Error(), "gpgeez: decrypting private key: "), err.Error())
+	err = VerifyArchiveIntegrity(data[:len(data)/2], []byte("correct horse"))
+	assert.True(t, strings.HasPrefix(err.Error(), "gpgeez: opening archive: "), err.Error())
+}
@@ -0,0 +1,92 @@
+package gpgeez
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+// maxArmorLineWidth is the longest armor line allowed by
+// https://tools.ietf.org/html/rfc4880#section-6.3
+const maxArmorLineWidth = 76
+
+// armorEncode is like armor.Encode, but wraps the base64 data at
+// config.ArmorLineWidth characters, if it is set. config may be nil.
+func (config *Config) armorEncode(out io.Writer, blockType string, headers map[string]string) (io.WriteCloser, error) {
+	if config == nil || config.ArmorLineWidth == 0 {
+		return armor.Encode(out, blockType, headers)
+	}
+	if err := checkArmorLineWidth(config.ArmorLineWidth); err != nil {
+		return nil, err
+	}
+	w := &lineWidthWriter{out: out, width: config.ArmorLineWidth}
+	var err error
+	w.WriteCloser, err = armor.Encode(&w.buf, blockType, headers)
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// checkArmorLineWidth returns an error if width isn't a valid
+// Config.ArmorLineWidth.
+func checkArmorLineWidth(width int) error {
+	if width < 0 || width > maxArmorLineWidth {
+		return fmt.Errorf("gpgeez: ArmorLineWidth must be between 1 and %d", maxArmorLineWidth)
+	}
+	return nil
+}
+
+// lineWidthWriter buffers the output of an armor encoder, and rewraps it to
+// width characters per line when it is closed. The vendored armor package
+// always uses 64.
+type lineWidthWriter struct {
+	io.WriteCloser
+	buf   bytes.Buffer
+	out   io.Writer
+	width int
+}
+
+func (w *lineWidthWriter) Close() error {
+	err := w.WriteCloser.Close()
+	if err != nil {
+		return err
+	}
+	_, err = w.out.Write(rewrapArmor(w.buf.Bytes(), w.width))
+	return err
+}
+
+// rewrapArmor rewraps the base64 data of an armored block, which is between
+// the blank line ending the headers and the checksum line, to width
+// characters per line.
+func rewrapArmor(data []byte, width int) []byte {
+	lines := strings.Split(string(data), "\n")
+	start := 0
+	for start < len(lines) && lines[start] != "" {
+		start++
+	}
+	end := start + 1
+	for end < len(lines) && !strings.HasPrefix(lines[end], "-----END ") {
+		end++
+	}
+	// The line before the armor tail is the checksum.
+	end--
+	if end >= len(lines)-1 || end <= start {
+		return data
+	}
+
+	body := strings.Join(lines[start+1:end], "")
+	r := append([]string(nil), lines[:start+1]...)
+	for len(body) > width {
+		r = append(r, body[:width])
+		body = body[width:]
+	}
+	if body != "" {
+		r = append(r, body)
+	}
+	r = append(r, lines[end:]...)
+	return []byte(strings.Join(r, "\n"))
+}
@@ -0,0 +1,117 @@
+package gpgeez
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func benchmarkCreateKey(b *testing.B, config *Config) {
+	for i := 0; i < b.N; i++ {
+		_, err := CreateKey("Joe", "test key", "joe@example.com", config)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCreateKeyRSA2048(b *testing.B) {
+	benchmarkCreateKey(b, &Config{Expiry: 365 * 24 * time.Hour, RSABits: 2048})
+}
+
+func BenchmarkCreateKeyRSA4096(b *testing.B) {
+	benchmarkCreateKey(b, &Config{Expiry: 365 * 24 * time.Hour, RSABits: 4096})
+}
+
+// The vendored openpgp package has no Ed25519 support, so P-256 is the
+// closest comparison.
+func BenchmarkCreateKeyP256(b *testing.B) {
+	benchmarkCreateKey(b, &Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"})
+}
+
+func benchmarkKey(b *testing.B) (*Key, *Config) {
+	config := &Config{Expiry: 365 * 24 * time.Hour}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", config)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	return key, config
+}
+
+var benchmarkMessage = bytes.Repeat([]byte("hello world\n"), 1000)
+
+func BenchmarkArmor(b *testing.B) {
+	key, _ := benchmarkKey(b)
+	for i := 0; i < b.N; i++ {
+		_, err := key.Armor()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkImportPublicKey(b *testing.B) {
+	key, _ := benchmarkKey(b)
+	armored, err := key.Armor()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := ImportPublicKey(armored)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSign(b *testing.B) {
+	key, config := benchmarkKey(b)
+	for i := 0; i < b.N; i++ {
+		_, err := key.Sign(bytes.NewReader(benchmarkMessage), config)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkVerify(b *testing.B) {
+	key, config := benchmarkKey(b)
+	sig, err := key.Sign(bytes.NewReader(benchmarkMessage), config)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := key.Verify(bytes.NewReader(benchmarkMessage), sig, config)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncrypt(b *testing.B) {
+	key, config := benchmarkKey(b)
+	for i := 0; i < b.N; i++ {
+		_, err := Encrypt(bytes.NewReader(benchmarkMessage), []*Key{key}, nil, config)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecrypt(b *testing.B) {
+	key, config := benchmarkKey(b)
+	ciphertext, err := Encrypt(bytes.NewReader(benchmarkMessage), []*Key{key}, nil, config)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := key.Decrypt(bytes.NewReader(ciphertext), config)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
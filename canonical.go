@@ -0,0 +1,99 @@
+package gpgeez
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// Canonicalize returns a copy of key with duplicate signatures removed,
+// expired and local (see Config.NonExportable) signatures made by other keys
+// pruned, and signatures and subkeys sorted by creation time. This is similar to GnuPG's
+// --export-options export-clean.
+//
+// The packets are written in the order given in
+// https://tools.ietf.org/html/rfc4880#section-11.1 when the key is
+// serialized. Identities are kept in a map by the openpgp package, so their
+// relative order is not fixed.
+func (key *Key) Canonicalize() *Key {
+	now := time.Now()
+	r := &Key{key.Entity}
+	r.Revocations = dedupSignatures(key.Revocations, nil, now)
+
+	r.Identities = make(map[string]*openpgp.Identity, len(key.Identities))
+	for name, id := range key.Identities {
+		clone := *id
+		clone.Signatures = dedupSignatures(exportableSignatures(id.Signatures), key.PrimaryKey, now)
+		r.Identities[name] = &clone
+	}
+
+	seen := make(map[uint64]bool)
+	r.Subkeys = nil
+	for _, subkey := range key.Subkeys {
+		if seen[subkey.PublicKey.KeyId] {
+			continue
+		}
+		seen[subkey.PublicKey.KeyId] = true
+		r.Subkeys = append(r.Subkeys, subkey)
+	}
+	sort.Stable(subkeysByCreationTime(r.Subkeys))
+	return r
+}
+
+// dedupSignatures returns sigs without duplicates, sorted by creation time.
+// If primary is not nil, expired signatures which weren't made by primary are
+// dropped as well.
+func dedupSignatures(sigs []*packet.Signature, primary *packet.PublicKey, now time.Time) []*packet.Signature {
+	var r []*packet.Signature
+	seen := make(map[string]bool)
+	for _, sig := range sigs {
+		if primary != nil && !isSelfSignature(sig, primary) && sigExpired(sig, now) {
+			continue
+		}
+		buf := new(bytes.Buffer)
+		if sig.Serialize(buf) != nil {
+			// Signatures which can't be serialized would be dropped on
+			// export anyway.
+			continue
+		}
+		if seen[buf.String()] {
+			continue
+		}
+		seen[buf.String()] = true
+		r = append(r, sig)
+	}
+	sort.Stable(signaturesByCreationTime(r))
+	return r
+}
+
+// sigExpired returns whether sig has a signature expiration time before now.
+func sigExpired(sig *packet.Signature, now time.Time) bool {
+	if sig.SigLifetimeSecs == nil || *sig.SigLifetimeSecs == 0 {
+		return false
+	}
+	expiry := sig.CreationTime.Add(time.Duration(*sig.SigLifetimeSecs) * time.Second)
+	return now.After(expiry)
+}
+
+func isSelfSignature(sig *packet.Signature, primary *packet.PublicKey) bool {
+	return sig.IssuerKeyId != nil && *sig.IssuerKeyId == primary.KeyId
+}
+
+type signaturesByCreationTime []*packet.Signature
+
+func (s signaturesByCreationTime) Len() int      { return len(s) }
+func (s signaturesByCreationTime) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s signaturesByCreationTime) Less(i, j int) bool {
+	return s[i].CreationTime.Before(s[j].CreationTime)
+}
+
+type subkeysByCreationTime []openpgp.Subkey
+
+func (s subkeysByCreationTime) Len() int      { return len(s) }
+func (s subkeysByCreationTime) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s subkeysByCreationTime) Less(i, j int) bool {
+	return s[i].PublicKey.CreationTime.Before(s[j].PublicKey.CreationTime)
+}
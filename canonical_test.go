@@ -0,0 +1,38 @@
+package gpgeez
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+func TestCanonicalize(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	other, err := CreateKey("Jane", "test key", "jane@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	id := key.primaryIdentity()
+	lifetime := uint32(60)
+	expired := &packet.Signature{
+		CreationTime:    time.Now().Add(-time.Hour),
+		SigType:         packet.SigTypeGenericCert,
+		PubKeyAlgo:      other.PrivateKey.PubKeyAlgo,
+		Hash:            config.Hash(),
+		IssuerKeyId:     &other.PrimaryKey.KeyId,
+		SigLifetimeSecs: &lifetime,
+	}
+	h, err := hashUserId(id.UserId.Id, key.PrimaryKey, expired)
+	assert.Nil(t, err)
+	expired, err = signWithSubpackets(expired, h, other.PrivateKey, nil, &config)
+	assert.Nil(t, err, "signWithSubpackets errored")
+	id.Signatures = append(id.Signatures, id.SelfSignature, expired, id.SelfSignature)
+
+	clean := key.Canonicalize()
+	assert.Equal(t, 1, len(clean.primaryIdentity().Signatures))
+	assert.Equal(t, 3, len(id.Signatures), "Canonicalize must not modify key")
+	assert.Equal(t, len(key.Subkeys), len(clean.Subkeys))
+}
@@ -0,0 +1,175 @@
+package gpgeez
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"errors"
+	"math/big"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// keyFlagAuthenticate is missing from the vendored openpgp package, see
+// https://tools.ietf.org/html/rfc4880#section-5.2.3.21
+const keyFlagAuthenticate = 0x20
+
+// Control reference templates of the OpenPGP card key slots.
+const (
+	cardSlotSignature      = 0xb6
+	cardSlotDecryption     = 0xb8
+	cardSlotAuthentication = 0xa4
+)
+
+// CardApplicationData returns the private keys of key in the key import
+// format of the OpenPGP smart card application, version 3.4, section 4.4.3.12:
+// one Extended header list (tag 4D) per key slot. Each list must be sent to
+// the card in its own PUT DATA command (INS DB, P1-P2 3FFF).
+//
+// The signature slot gets the signing subkey if there is one, or the primary
+// key otherwise. The decryption slot gets the encryption subkey, and the
+// authentication slot a subkey with the authentication flag, if there is
+// one. Only RSA and ECDSA keys can be moved to a card. RSA keys use the
+// standard import format (e, p, q), with e in as few bytes as possible; check
+// that the card's algorithm attributes match before importing.
+//
+// The private keys must be decrypted. Handle the result like the output of
+// ArmorPrivate.
+func (key *Key) CardApplicationData() ([]byte, error) {
+	if key == nil {
+		return nil, ErrNilKey
+	}
+	now := time.Now()
+	slots := []struct {
+		crt  byte
+		priv *packet.PrivateKey
+	}{
+		{cardSlotSignature, key.PrivateKey},
+		{cardSlotDecryption, nil},
+		{cardSlotAuthentication, nil},
+	}
+	for _, subkey := range key.Subkeys {
+		if subkey.PrivateKey == nil || !isActiveSubkey(subkey, now) || !subkey.Sig.FlagsValid {
+			continue
+		}
+		switch {
+		case isAuthenticationSubkey(subkey):
+			if slots[2].priv == nil {
+				slots[2].priv = subkey.PrivateKey
+			}
+		case subkey.Sig.FlagSign:
+			if slots[0].priv == key.PrivateKey {
+				slots[0].priv = subkey.PrivateKey
+			}
+		case isEncryptionSubkey(subkey):
+			if slots[1].priv == nil {
+				slots[1].priv = subkey.PrivateKey
+			}
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	for _, slot := range slots {
+		if slot.priv == nil {
+			continue
+		}
+		priv, err := checkPrivateKey(slot.priv)
+		if err != nil {
+			return nil, err
+		}
+		data, err := cardKeyImport(slot.crt, priv)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
+
+// cardKeyImport returns the Extended header list which imports priv into the
+// slot identified by crt.
+func cardKeyImport(crt byte, priv *packet.PrivateKey) ([]byte, error) {
+	var values [][]byte
+	var tags []byte
+	switch k := priv.PrivateKey.(type) {
+	case *rsa.PrivateKey:
+		if len(k.Primes) != 2 {
+			return nil, errors.New("gpgeez: multi-prime RSA keys cannot be imported into a card")
+		}
+		size := (k.N.BitLen()/2 + 7) / 8
+		tags = []byte{0x91, 0x92, 0x93}
+		values = [][]byte{
+			big.NewInt(int64(k.E)).Bytes(),
+			paddedBytes(k.Primes[0], size),
+			paddedBytes(k.Primes[1], size),
+		}
+	case *ecdsa.PrivateKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		tags = []byte{0x92, 0x99}
+		values = [][]byte{
+			paddedBytes(k.D, size),
+			elliptic.Marshal(k.Curve, k.X, k.Y),
+		}
+	default:
+		return nil, errors.New("gpgeez: only RSA and ECDSA keys can be imported into a card")
+	}
+
+	template := new(bytes.Buffer)
+	data := new(bytes.Buffer)
+	for i, value := range values {
+		template.WriteByte(tags[i])
+		writeBERLength(template, len(value))
+		data.Write(value)
+	}
+
+	body := new(bytes.Buffer)
+	body.Write([]byte{crt, 0})
+	writeBERTLV(body, []byte{0x7f, 0x48}, template.Bytes())
+	writeBERTLV(body, []byte{0x5f, 0x48}, data.Bytes())
+	r := new(bytes.Buffer)
+	writeBERTLV(r, []byte{0x4d}, body.Bytes())
+	return r.Bytes(), nil
+}
+
+func isAuthenticationSubkey(subkey openpgp.Subkey) bool {
+	subpackets, err := hashedSubpackets(subkey.Sig)
+	if err != nil {
+		return false
+	}
+	for _, sp := range subpackets {
+		if sp.subpacketType == subpacketKeyFlags && len(sp.contents) > 0 && sp.contents[0]&keyFlagAuthenticate != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// paddedBytes returns n in big endian, left-padded with zeros to size bytes.
+func paddedBytes(n *big.Int, size int) []byte {
+	b := n.Bytes()
+	if len(b) >= size {
+		return b
+	}
+	return append(make([]byte, size-len(b)), b...)
+}
+
+func writeBERTLV(w *bytes.Buffer, tag []byte, value []byte) {
+	w.Write(tag)
+	writeBERLength(w, len(value))
+	w.Write(value)
+}
+
+// writeBERLength writes a BER-TLV length, see ISO/IEC 7816-4 section 5.2.2.
+func writeBERLength(w *bytes.Buffer, length int) {
+	switch {
+	case length < 0x80:
+		w.WriteByte(byte(length))
+	case length <= 0xff:
+		w.Write([]byte{0x81, byte(length)})
+	default:
+		w.Write([]byte{0x82, byte(length >> 8), byte(length)})
+	}
+}
@@ -0,0 +1,70 @@
+package gpgeez
+
+import (
+	"crypto/rsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// readBERTLV splits data into its first TLV's tag, value and the rest.
+func readBERTLV(t *testing.T, data []byte) (tag, value, rest []byte) {
+	n := 1
+	if data[0]&0x1f == 0x1f {
+		n = 2
+	}
+	tag, data = data[:n], data[n:]
+	length := int(data[0])
+	switch data[0] {
+	case 0x81:
+		length, data = int(data[1]), data[2:]
+	case 0x82:
+		length, data = int(data[1])<<8|int(data[2]), data[3:]
+	default:
+		data = data[1:]
+	}
+	if !assert.True(t, length <= len(data), "truncated TLV") {
+		t.FailNow()
+	}
+	return tag, data[:length], data[length:]
+}
+
+func TestCardApplicationData(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	data, err := key.CardApplicationData()
+	assert.Nil(t, err, "CardApplicationData errored")
+	privs := []*rsa.PrivateKey{
+		key.PrivateKey.PrivateKey.(*rsa.PrivateKey),
+		key.Subkeys[0].PrivateKey.PrivateKey.(*rsa.PrivateKey),
+	}
+	for i, crt := range []byte{cardSlotSignature, cardSlotDecryption} {
+		tag, body, rest := readBERTLV(t, data)
+		data = rest
+		assert.Equal(t, []byte{0x4d}, tag)
+		assert.Equal(t, []byte{crt, 0}, body[:2])
+		tag, template, body := readBERTLV(t, body[2:])
+		assert.Equal(t, []byte{0x7f, 0x48}, tag)
+		assert.Equal(t, []byte{0x91, 3, 0x92, 0x81, 128, 0x93, 0x81, 128}, template)
+		tag, values, _ := readBERTLV(t, body)
+		assert.Equal(t, []byte{0x5f, 0x48}, tag)
+		assert.Equal(t, 3+128+128, len(values))
+
+		assert.Equal(t, []byte{1, 0, 1}, values[:3])
+		p := new(big.Int).SetBytes(values[3:131])
+		q := new(big.Int).SetBytes(values[131:])
+		assert.Equal(t, privs[i].N, new(big.Int).Mul(p, q))
+	}
+	assert.Equal(t, 0, len(data))
+
+	config.KeyType = "DSA"
+	config.DSABits = 1024
+	key, err = CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	_, err = key.CardApplicationData()
+	assert.NotNil(t, err, "DSA keys can't be moved to a card")
+}
@@ -0,0 +1,215 @@
+package gpgeez
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// CertifyKey makes signer certify target with a certification of type
+// certType: packet.SigTypeGenericCert, SigTypePersonaCert, SigTypeCasualCert
+// or SigTypePositiveCert, which state how carefully signer checked target's
+// identity.
+//
+// OpenPGP certifications always bind the key to a user id, see
+// https://tools.ietf.org/html/rfc4880#section-5.2.1, so like gpg --sign-key,
+// each of target's user ids is certified and the certifications are added to
+// the identities' signatures. If anything fails, target is left unchanged.
+func (signer *Key) CertifyKey(target *Key, certType packet.SignatureType, config *Config) error {
+	if signer == nil || target == nil {
+		return ErrNilKey
+	}
+	if !isCertification(certType) {
+		return errors.New("gpgeez: not a certification signature type")
+	}
+	if signer.Fingerprint() == target.Fingerprint() {
+		return errors.New("gpgeez: a key cannot certify itself")
+	}
+
+	uids := make([]string, 0, len(target.Identities))
+	for uid := range target.Identities {
+		uids = append(uids, uid)
+	}
+	sort.Strings(uids)
+	sigs := make([]*packet.Signature, len(uids))
+	for i, uid := range uids {
+		sig, err := signer.certifyUID(target, target.Identities[uid], certType, nil, config)
+		if err != nil {
+			return err
+		}
+		sigs[i] = sig
+	}
+	for i, uid := range uids {
+		id := target.Identities[uid]
+		id.Signatures = append(id.Signatures, sigs[i])
+	}
+	return nil
+}
+
+// certifyUID returns a certification of type certType, made by signer's
+// primary key, of id on target. The extra subpackets are added to the hashed
+// area, along with an exportable certification subpacket if
+// config.NonExportable is set.
+func (signer *Key) certifyUID(target *Key, id *openpgp.Identity, certType packet.SignatureType, extra []subpacket, config *Config) (*packet.Signature, error) {
+	priv, err := checkPrivateKey(signer.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	sig := &packet.Signature{
+		CreationTime: config.now(),
+		SigType:      certType,
+		PubKeyAlgo:   priv.PubKeyAlgo,
+		Hash:         config.hashFor(HashForUIDCertification),
+		IssuerKeyId:  &priv.KeyId,
+	}
+	h, err := hashUserId(id.UserId.Id, target.PrimaryKey, sig)
+	if err != nil {
+		return nil, err
+	}
+	if config.NonExportable {
+		// The subpacket isn't marked critical, since the vendored openpgp
+		// package rejects signatures with unknown critical subpackets.
+		extra = append(extra, subpacket{subpacketExportableCert, false, []byte{0}})
+	}
+	return signWithSubpackets(sig, h, priv, extra, config)
+}
+
+// exportable returns key without its local certifications, or key itself if
+// it has none.
+func (key *Key) exportable() *Key {
+	local := false
+	for _, id := range key.Identities {
+		local = local || len(exportableSignatures(id.Signatures)) != len(id.Signatures)
+	}
+	if !local {
+		return key
+	}
+	r := &Key{key.Entity}
+	r.Identities = make(map[string]*openpgp.Identity, len(key.Identities))
+	for name, id := range key.Identities {
+		clone := *id
+		clone.Signatures = exportableSignatures(id.Signatures)
+		r.Identities[name] = &clone
+	}
+	return r
+}
+
+// exportableSignatures returns the signatures in sigs which aren't marked as
+// non-exportable.
+func exportableSignatures(sigs []*packet.Signature) []*packet.Signature {
+	var r []*packet.Signature
+	for _, sig := range sigs {
+		if isExportable(sig) {
+			r = append(r, sig)
+		}
+	}
+	return r
+}
+
+func isExportable(sig *packet.Signature) bool {
+	subpackets, err := hashedSubpackets(sig)
+	if err != nil {
+		return true
+	}
+	for _, sp := range subpackets {
+		if sp.subpacketType == subpacketExportableCert && len(sp.contents) == 1 && sp.contents[0] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// TrustCertifyUID makes signer certify the identity uid of target with a trust
+// signature, see https://tools.ietf.org/html/rfc4880#section-5.2.3.13. uid
+// must be the full user id, e.g. "Joe (test key) <joe@example.com>".
+//
+// depth is how far the delegation goes: 1 makes target a trusted introducer,
+// whose certifications are trusted like signer's, and higher levels let
+// target delegate further. amount is how much target is trusted: 60 for
+// partial and 120 for complete trust, as used by gpg --tsign. If
+// config.TrustRegex is set, the delegation is limited to the user ids which
+// match it. The regular expression subpacket isn't marked critical, since the
+// vendored openpgp package rejects signatures with unknown critical
+// subpackets, so implementations which don't understand it may ignore it.
+func (signer *Key) TrustCertifyUID(target *Key, uid string, depth uint8, amount uint8, config *Config) error {
+	if signer == nil || target == nil {
+		return ErrNilKey
+	}
+	id, ok := target.Identities[uid]
+	if !ok {
+		return errors.New("gpgeez: no such identity " + uid)
+	}
+	extra := []subpacket{{subpacketTrustSignature, false, []byte{depth, amount}}}
+	if config.TrustRegex != "" {
+		_, err := regexp.Compile(config.TrustRegex)
+		if err != nil {
+			return fmt.Errorf("gpgeez: invalid TrustRegex: %w", err)
+		}
+		// The regular expression is a null-terminated string.
+		extra = append(extra, subpacket{subpacketRegularExpression, false, append([]byte(config.TrustRegex), 0)})
+	}
+	sig, err := signer.certifyUID(target, id, packet.SigTypeGenericCert, extra, config)
+	if err != nil {
+		return err
+	}
+	id.Signatures = append(id.Signatures, sig)
+	return nil
+}
+
+// BuildCertChain makes ca certify the identity uid of subject with a trust
+// signature of depth 1 and complete trust, so that ca acts as a certificate
+// authority for subject. It returns subject, which is modified in place.
+func BuildCertChain(ca *Key, subject *Key, uid string, config *Config) (*Key, error) {
+	err := ca.TrustCertifyUID(subject, uid, 1, 120, config)
+	if err != nil {
+		return nil, err
+	}
+	return subject, nil
+}
+
+// VerifyCertChain returns nil if one of the user ids of subject carries a
+// valid trust signature made by ca, as created by BuildCertChain, which
+// hasn't expired or been revoked.
+func VerifyCertChain(subject *Key, ca *Key) error {
+	if subject == nil || ca == nil {
+		return ErrNilKey
+	}
+	now := time.Now()
+	for _, id := range subject.Identities {
+		for _, sig := range id.Signatures {
+			if !issuedBy(sig, ca) || !isCertification(sig.SigType) || sigExpired(sig, now) {
+				continue
+			}
+			if depth, ok := trustDepth(sig); !ok || depth < 1 {
+				continue
+			}
+			if ca.PrimaryKey.VerifyUserIdSignature(id.UserId.Id, subject.PrimaryKey, sig) != nil {
+				continue
+			}
+			if !certificationRevoked(ca, subject, id.UserId.Id, id.Signatures, sig.CreationTime) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("gpgeez: %s has no valid trust signature by %s", subject.Fingerprint(), ca.Fingerprint())
+}
+
+// trustDepth returns the depth of the trust signature subpacket of sig, if it
+// has one.
+func trustDepth(sig *packet.Signature) (uint8, bool) {
+	subpackets, err := hashedSubpackets(sig)
+	if err != nil {
+		return 0, false
+	}
+	for _, sp := range subpackets {
+		if sp.subpacketType == subpacketTrustSignature && len(sp.contents) == 2 {
+			return sp.contents[0], true
+		}
+	}
+	return 0, false
+}
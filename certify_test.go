@@ -0,0 +1,117 @@
+package gpgeez
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+func TestCertifyKey(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	alice, err := CreateKey("Alice", "", "alice@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	bob, err := CreateKey("Bob", "", "bob@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	assert.Nil(t, alice.CertifyKey(bob, packet.SigTypeCasualCert, &config))
+	id := bob.primaryIdentity()
+	assert.Equal(t, 1, len(id.Signatures))
+	assert.Equal(t, packet.SignatureType(packet.SigTypeCasualCert), id.Signatures[0].SigType)
+	assert.Nil(t, alice.PrimaryKey.VerifyUserIdSignature(id.UserId.Id, bob.PrimaryKey, id.Signatures[0]))
+	assert.True(t, certifies(alice, bob, time.Now()))
+
+	assert.NotNil(t, alice.CertifyKey(bob, packet.SigTypeBinary, &config))
+	assert.NotNil(t, alice.CertifyKey(alice, packet.SigTypePositiveCert, &config))
+	assert.Equal(t, 1, len(id.Signatures))
+}
+
+func TestTrustCertifyUID(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	ca, err := CreateKey("CA", "", "ca@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	joe, err := CreateKey("Joe", "", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	assert.Nil(t, ca.TrustCertifyUID(joe, "Joe <joe@example.com>", 1, 120, &config))
+	assert.NotNil(t, ca.TrustCertifyUID(joe, "Joe", 1, 120, &config))
+	id := joe.Identities["Joe <joe@example.com>"]
+	assert.Equal(t, 1, len(id.Signatures))
+	assert.Nil(t, ca.PrimaryKey.VerifyUserIdSignature(id.UserId.Id, joe.PrimaryKey, id.Signatures[0]))
+
+	subpackets, err := hashedSubpackets(id.Signatures[0])
+	assert.Nil(t, err, "hashedSubpackets errored")
+	assert.Contains(t, subpackets, subpacket{subpacketTrustSignature, false, []byte{1, 120}})
+
+	config.TrustRegex = "<[^>]+[@.]example\\.com>$"
+	assert.Nil(t, ca.TrustCertifyUID(joe, "Joe <joe@example.com>", 1, 60, &config))
+	subpackets, err = hashedSubpackets(id.Signatures[1])
+	assert.Nil(t, err, "hashedSubpackets errored")
+	assert.Contains(t, subpackets, subpacket{subpacketRegularExpression, false, []byte("<[^>]+[@.]example\\.com>$\x00")})
+
+	config.TrustRegex = "example(.com"
+	assert.NotNil(t, ca.TrustCertifyUID(joe, "Joe <joe@example.com>", 1, 60, &config))
+	assert.Equal(t, 2, len(id.Signatures))
+}
+
+func TestNonExportable(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	alice, err := CreateKey("Alice", "", "alice@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	bob, err := CreateKey("Bob", "", "bob@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	carol, err := CreateKey("Carol", "", "carol@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	assert.Nil(t, carol.CertifyKey(bob, packet.SigTypeGenericCert, &config))
+	local := config
+	local.NonExportable = true
+	assert.Nil(t, alice.CertifyKey(bob, packet.SigTypeGenericCert, &local))
+	id := bob.primaryIdentity()
+	assert.Equal(t, 2, len(id.Signatures))
+	assert.False(t, isExportable(id.Signatures[1]))
+	assert.True(t, certifies(alice, bob, time.Now()), "local certifications are valid")
+
+	exported, err := ImportPublicKey(mustArmor(t, bob))
+	assert.Nil(t, err, "ImportPublicKey errored")
+	assert.Equal(t, 1, len(exported.primaryIdentity().Signatures))
+	assert.True(t, certifies(carol, exported, time.Now()))
+	assert.False(t, certifies(alice, exported, time.Now()))
+	assert.Equal(t, 1, len(bob.Canonicalize().primaryIdentity().Signatures))
+	assert.Equal(t, 2, len(id.Signatures), "bob must not be modified")
+}
+
+func TestCertChain(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	ca, err := CreateKey("CA", "", "ca@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	joe, err := CreateKey("Joe", "", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	eve, err := CreateKey("Eve", "", "eve@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	assert.NotNil(t, VerifyCertChain(joe, ca))
+	_, err = BuildCertChain(ca, joe, "Joe", &config)
+	assert.NotNil(t, err, "no such identity")
+
+	assert.Nil(t, ca.CertifyKey(joe, packet.SigTypeGenericCert, &config))
+	assert.NotNil(t, VerifyCertChain(joe, ca), "a plain certification is not a trust signature")
+
+	subject, err := BuildCertChain(ca, joe, "Joe <joe@example.com>", &config)
+	assert.Nil(t, err, "BuildCertChain errored")
+	assert.Equal(t, joe, subject)
+	assert.Nil(t, VerifyCertChain(joe, ca))
+	assert.NotNil(t, VerifyCertChain(joe, eve))
+
+	imported, err := ImportPublicKey(mustArmor(t, joe))
+	assert.Nil(t, err, "ImportPublicKey errored")
+	assert.Nil(t, VerifyCertChain(imported, ca))
+
+	// ca's trust signature doesn't verify when relabelled with eve's key id.
+	id := joe.primaryIdentity()
+	forged := *id.Signatures[len(id.Signatures)-1]
+	forged.IssuerKeyId = &eve.PrimaryKey.KeyId
+	id.Signatures = append(id.Signatures[:1], &forged)
+	assert.NotNil(t, VerifyCertChain(joe, eve))
+}
@@ -0,0 +1,186 @@
+package gpgeez
+
+import (
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"golang.org/x/crypto/openpgp/packet"
+	"golang.org/x/crypto/openpgp/s2k"
+)
+
+// jsonConfig is the document read by LoadConfig. Durations are strings in the
+// format of time.ParseDuration, e.g. "8760h".
+type jsonConfig struct {
+	Expiry              *string `json:"expiry"`
+	KeyType             *string `json:"key_type"`
+	RSABits             *int    `json:"rsa_bits"`
+	DSABits             *int    `json:"dsa_bits"`
+	SubkeyType          *string `json:"subkey_type"`
+	Curve               *string `json:"curve"`
+	Hash                *string `json:"hash"`
+	Cipher              *string `json:"cipher"`
+	Compression         *string `json:"compression"`
+	PreferredKeyserver  *string `json:"preferred_keyserver"`
+	MaxKeyLifetime      *string `json:"max_key_lifetime"`
+	ClampExpiry         *bool   `json:"clamp_expiry"`
+	SignatureExpiry     *string `json:"signature_expiry"`
+	AllowWeakAlgorithms *bool   `json:"allow_weak_algorithms"`
+
+	PreferredHash    []string          `json:"preferred_hash"`
+	HashAlgorithmFor map[string]string `json:"hash_algorithm_for"`
+}
+
+var hashNames = map[string]crypto.Hash{
+	"SHA1":   crypto.SHA1,
+	"SHA224": crypto.SHA224,
+	"SHA256": crypto.SHA256,
+	"SHA384": crypto.SHA384,
+	"SHA512": crypto.SHA512,
+}
+
+var cipherNames = map[string]packet.CipherFunction{
+	"3DES":   packet.Cipher3DES,
+	"CAST5":  packet.CipherCAST5,
+	"AES128": packet.CipherAES128,
+	"AES192": packet.CipherAES192,
+	"AES256": packet.CipherAES256,
+}
+
+var compressionNames = map[string]packet.CompressionAlgo{
+	"none": packet.CompressionNone,
+	"ZIP":  packet.CompressionZIP,
+	"ZLIB": packet.CompressionZLIB,
+}
+
+// LoadConfig reads a JSON document describing a key configuration, e.g.
+//
+//	{"expiry": "8760h", "key_type": "RSA", "rsa_bits": 4096, "hash": "SHA512"}
+//
+// and returns DefaultConfig with the fields present in the document
+// overridden. Hashes are named "SHA1" to "SHA512", ciphers "3DES", "CAST5" or
+// "AES128" to "AES256", and compression algorithms "none", "ZIP" or "ZLIB".
+// "preferred_hash" is a list of hash names, and "hash_algorithm_for" maps
+// the operation names of Config.HashAlgorithmFor to hash names. Unknown
+// fields, and data after the document, are rejected, so that typos don't
+// silently fall back to the defaults. The config is checked like
+// ValidateConfig does.
+//
+// Only the fields above can be set. In particular there is no policy URL,
+// since Config has no such setting, and the settings which aren't plain
+// values, such as Rand, Now and Logger, must be set in code.
+//
+// YAML documents are not supported, since that would require vendoring a YAML
+// parser. Convert them to JSON first.
+func LoadConfig(r io.Reader) (*Config, error) {
+	var c jsonConfig
+	d := json.NewDecoder(r)
+	d.DisallowUnknownFields()
+	err := d.Decode(&c)
+	if err != nil {
+		return nil, fmt.Errorf("gpgeez: reading config: %w", err)
+	}
+	if d.More() {
+		return nil, errors.New("gpgeez: reading config: unexpected data after the document")
+	}
+
+	config := DefaultConfig()
+	durations := []struct {
+		name  string
+		value *string
+		field *time.Duration
+	}{
+		{"expiry", c.Expiry, &config.Expiry},
+		{"max_key_lifetime", c.MaxKeyLifetime, &config.MaxKeyLifetime},
+		{"signature_expiry", c.SignatureExpiry, &config.SignatureExpiry},
+	}
+	for _, f := range durations {
+		if f.value == nil {
+			continue
+		}
+		*f.field, err = time.ParseDuration(*f.value)
+		if err != nil {
+			return nil, fmt.Errorf("gpgeez: reading config: %s: %w", f.name, err)
+		}
+	}
+	if c.Hash != nil {
+		h, ok := hashNames[*c.Hash]
+		if !ok {
+			return nil, fmt.Errorf("gpgeez: reading config: unknown hash %q", *c.Hash)
+		}
+		config.DefaultHash = h
+	}
+	if c.Cipher != nil {
+		cipher, ok := cipherNames[*c.Cipher]
+		if !ok {
+			return nil, fmt.Errorf("gpgeez: reading config: unknown cipher %q", *c.Cipher)
+		}
+		config.DefaultCipher = cipher
+	}
+	if c.Compression != nil {
+		algo, ok := compressionNames[*c.Compression]
+		if !ok {
+			return nil, fmt.Errorf("gpgeez: reading config: unknown compression algorithm %q", *c.Compression)
+		}
+		config.DefaultCompressionAlgo = algo
+	}
+	if c.KeyType != nil {
+		config.KeyType = *c.KeyType
+	}
+	if c.RSABits != nil {
+		config.RSABits = *c.RSABits
+	}
+	if c.DSABits != nil {
+		config.DSABits = *c.DSABits
+	}
+	if c.SubkeyType != nil {
+		config.SubkeyType = *c.SubkeyType
+	}
+	if c.Curve != nil {
+		config.Curve = *c.Curve
+	}
+	if c.PreferredKeyserver != nil {
+		config.PreferredKeyserver = *c.PreferredKeyserver
+	}
+	if c.ClampExpiry != nil {
+		config.ClampExpiry = *c.ClampExpiry
+	}
+	if c.AllowWeakAlgorithms != nil {
+		config.AllowWeakAlgorithms = *c.AllowWeakAlgorithms
+	}
+	for _, name := range c.PreferredHash {
+		h, err := hashAlgorithmByName(name)
+		if err != nil {
+			return nil, err
+		}
+		config.PreferredHash = append(config.PreferredHash, h)
+	}
+	if c.HashAlgorithmFor != nil {
+		config.HashAlgorithmFor = make(map[string]HashAlgorithm, len(c.HashAlgorithmFor))
+		for operation, name := range c.HashAlgorithmFor {
+			h, err := hashAlgorithmByName(name)
+			if err != nil {
+				return nil, err
+			}
+			config.HashAlgorithmFor[operation] = h
+		}
+	}
+
+	err = config.validate()
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// hashAlgorithmByName returns the HashAlgorithm named name in hashNames.
+func hashAlgorithmByName(name string) (HashAlgorithm, error) {
+	id, ok := s2k.HashToHashId(hashNames[name])
+	if !ok {
+		return 0, fmt.Errorf("gpgeez: reading config: unknown hash %q", name)
+	}
+	return HashAlgorithm(id), nil
+}
@@ -0,0 +1,60 @@
+package gpgeez
+
+import (
+	"crypto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+func TestLoadConfig(t *testing.T) {
+	config, err := LoadConfig(strings.NewReader(`{
+		"expiry": "720h",
+		"curve": "P-384",
+		"hash": "SHA512",
+		"cipher": "AES128",
+		"preferred_keyserver": "hkps://keys.example.com"
+	}`))
+	assert.Nil(t, err, "LoadConfig errored")
+	expected := DefaultConfig()
+	expected.Expiry = 720 * time.Hour
+	expected.Curve = "P-384"
+	expected.DefaultHash = crypto.SHA512
+	expected.DefaultCipher = packet.CipherAES128
+	expected.PreferredKeyserver = "hkps://keys.example.com"
+	assert.Equal(t, expected, config)
+
+	_, err = LoadConfig(strings.NewReader(`{"expiry": "1 year"}`))
+	assert.NotNil(t, err, "invalid durations must be rejected")
+	_, err = LoadConfig(strings.NewReader(`{"hash": "MD5"}`))
+	assert.NotNil(t, err, "unknown hashes must be rejected")
+	_, err = LoadConfig(strings.NewReader(`{"rsabits": 4096}`))
+	assert.NotNil(t, err, "unknown fields must be rejected")
+	_, err = LoadConfig(strings.NewReader(`{"preferred_keyserver": "example.com"}`))
+	assert.NotNil(t, err, "the config must be validated")
+	_, err = LoadConfig(strings.NewReader(`{"curve": "P-256"} {"curve": "P-384"}`))
+	assert.NotNil(t, err, "trailing data must be rejected")
+}
+
+func TestLoadConfigHashes(t *testing.T) {
+	config, err := LoadConfig(strings.NewReader(`{
+		"preferred_hash": ["SHA512", "SHA384", "SHA256"],
+		"hash_algorithm_for": {"data_signature": "SHA384"}
+	}`))
+	assert.Nil(t, err, "LoadConfig errored")
+	assert.Equal(t, []HashAlgorithm{sha512, sha384, sha256}, config.PreferredHash)
+	assert.Equal(t, map[string]HashAlgorithm{HashForDataSignature: sha384}, config.HashAlgorithmFor)
+
+	_, err = LoadConfig(strings.NewReader(`{"preferred_hash": ["SHA3"]}`))
+	assert.NotNil(t, err, "unknown hashes must be rejected")
+	_, err = LoadConfig(strings.NewReader(`{"hash_algorithm_for": {"encryption": "SHA256"}}`))
+	assert.NotNil(t, err, "unknown operations must be rejected")
+	_, err = LoadConfig(strings.NewReader(`{
+		"preferred_hash": ["SHA512", "SHA256"],
+		"hash_algorithm_for": {"data_signature": "SHA384"}
+	}`))
+	assert.NotNil(t, err, "signing hashes must be in the preferences")
+}
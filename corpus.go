@@ -0,0 +1,199 @@
+package gpgeez
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// corpusConfigs are the key types used by GenerateFuzzCorpus. They are kept
+// small, since the keys are only used to exercise the parsers.
+var corpusConfigs = []Config{
+	{RSABits: 2048},
+	{KeyType: "DSA", DSABits: 1024},
+	{Curve: "P-256"},
+	{Curve: "P-384"},
+	{RSABits: 2048, SubkeyType: "ElGamal"},
+}
+
+var corpusExpiries = []time.Duration{0, time.Hour, 365 * 24 * time.Hour}
+
+// corpusMaxUIDs is the largest number of user ids of a corpus key. It is
+// coprime with len(corpusExpiries), so that every number of user ids is
+// paired with every expiry.
+const corpusMaxUIDs = 4
+
+// corpusEntry is an entry of the manifest written by GenerateFuzzCorpus.
+type corpusEntry struct {
+	File        string `json:"file"`
+	Fingerprint string `json:"fingerprint"`
+	Algorithm   string `json:"algorithm"`
+	SubkeyType  string `json:"subkey_type"`
+	Expiry      string `json:"expiry"`
+	UIDs        int    `json:"uids"`
+	Private     bool   `json:"private"`
+	Passphrase  string `json:"passphrase,omitempty"`
+}
+
+// GenerateFuzzCorpus creates n keys, cycling through several algorithms,
+// expiries and numbers of user ids, and writes them to dir in armored format:
+// key-N.pub.asc for the public key and key-N.sec.asc for the private key.
+// Every other private key is protected with a passphrase. A manifest.json
+// describes each file. The files can be used to seed stress tests of the
+// parsing and operational functions.
+//
+// The keys are also written in the corpus format of Go's native fuzzer, to
+// dir/fuzz/FuzzParseArmoredKey for all the keys and
+// dir/fuzz/FuzzImportPrivateKey for the private keys. Copying dir/fuzz to
+// testdata/fuzz makes go test use them as seeds of this package's fuzz tests.
+//
+// The keys are generated in parallel, on all CPUs. They use crypto/rand and
+// are meant for testing only; the passphrases are written to the manifest.
+func GenerateFuzzCorpus(dir string, n int) error {
+	if n < 0 {
+		return errors.New("gpgeez: the number of corpus keys can't be negative")
+	}
+	for _, target := range fuzzTargets {
+		err := os.MkdirAll(filepath.Join(dir, fuzzCorpusDir, target), 0755)
+		if err != nil {
+			return err
+		}
+	}
+	entries := make([][]corpusEntry, n)
+	jobs := make(chan int)
+	errs := make(chan error, n)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				e, err := generateCorpusKey(dir, i)
+				if err != nil {
+					errs <- err
+					continue
+				}
+				entries[i] = e
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	close(errs)
+	if err := <-errs; err != nil {
+		return err
+	}
+
+	manifest := []corpusEntry{}
+	for _, e := range entries {
+		manifest = append(manifest, e...)
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "manifest.json"), data, 0644)
+}
+
+// generateCorpusKey creates the i-th key of a corpus, writes it to dir and
+// returns the manifest entries of the files.
+func generateCorpusKey(dir string, i int) ([]corpusEntry, error) {
+	config := corpusConfigs[i%len(corpusConfigs)]
+	config.Expiry = corpusExpiries[i%len(corpusExpiries)]
+	uids := make([]UID, 1+i%corpusMaxUIDs)
+	for j := range uids {
+		uids[j] = UID{fmt.Sprintf("Fuzz %d", i), fmt.Sprintf("uid %d", j), fmt.Sprintf("fuzz%d.%d@example.com", i, j)}
+	}
+	key, err := CreateKeyMultiUID(uids, &config)
+	if err != nil {
+		return nil, fmt.Errorf("gpgeez: corpus key %d: %w", i, err)
+	}
+
+	entry := corpusEntry{
+		Fingerprint: key.Fingerprint(),
+		Algorithm:   algorithmName(key.PrimaryKey.PubKeyAlgo),
+		SubkeyType:  algorithmName(key.Subkeys[0].PublicKey.PubKeyAlgo),
+		Expiry:      config.Expiry.String(),
+		UIDs:        len(uids),
+	}
+	public := entry
+	public.File = fmt.Sprintf("key-%d.pub.asc", i)
+	armored, err := key.Armor()
+	if err != nil {
+		return nil, err
+	}
+	err = writeCorpusFile(dir, public.File, []byte(armored), 0644, fuzzParseArmoredKey)
+	if err != nil {
+		return nil, err
+	}
+
+	private := entry
+	private.File = fmt.Sprintf("key-%d.sec.asc", i)
+	private.Private = true
+	var passphrase []byte
+	if i%2 == 1 {
+		private.Passphrase = fmt.Sprintf("passphrase %d", i)
+		passphrase = []byte(private.Passphrase)
+	}
+	buf := new(bytes.Buffer)
+	w, err := config.armorEncode(buf, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = key.serializeProtected(w, passphrase, &config)
+	if err != nil {
+		return nil, err
+	}
+	w.Close()
+	err = writeCorpusFile(dir, private.File, buf.Bytes(), 0600, fuzzParseArmoredKey, fuzzImportPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return []corpusEntry{public, private}, nil
+}
+
+// The fuzz tests seeded by GenerateFuzzCorpus. They take the armored key as
+// their only argument.
+const (
+	fuzzCorpusDir        = "fuzz"
+	fuzzParseArmoredKey  = "FuzzParseArmoredKey"
+	fuzzImportPrivateKey = "FuzzImportPrivateKey"
+)
+
+var fuzzTargets = []string{fuzzParseArmoredKey, fuzzImportPrivateKey}
+
+// writeCorpusFile writes armored to dir/name, and as a seed of each of the
+// fuzz tests in targets.
+func writeCorpusFile(dir, name string, armored []byte, perm os.FileMode, targets ...string) error {
+	err := ioutil.WriteFile(filepath.Join(dir, name), armored, perm)
+	if err != nil {
+		return err
+	}
+	seed := []byte(fuzzSeed(string(armored)))
+	for _, target := range targets {
+		err = ioutil.WriteFile(filepath.Join(dir, fuzzCorpusDir, target, name), seed, perm)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fuzzSeed encodes s as a corpus file of a fuzz test taking a single string,
+// in the format written by go test -fuzz.
+func fuzzSeed(s string) string {
+	return "go test fuzz v1\nstring(" + strconv.Quote(s) + ")\n"
+}
@@ -0,0 +1,74 @@
+package gpgeez
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateFuzzCorpus(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gpgeez")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NotNil(t, GenerateFuzzCorpus(dir, -1))
+	assert.Nil(t, GenerateFuzzCorpus(dir, 6))
+	data, err := ioutil.ReadFile(filepath.Join(dir, "manifest.json"))
+	assert.Nil(t, err, "manifest.json is missing")
+	var manifest []corpusEntry
+	assert.Nil(t, json.Unmarshal(data, &manifest))
+	assert.Equal(t, 12, len(manifest))
+	combinations := make(map[string]bool)
+	for _, entry := range manifest {
+		combinations[fmt.Sprintf("%s/%d", entry.Expiry, entry.UIDs)] = true
+	}
+	assert.Equal(t, 6, len(combinations), "expiries and user ids should vary independently")
+
+	for _, entry := range manifest {
+		armored, err := ioutil.ReadFile(filepath.Join(dir, entry.File))
+		assert.Nil(t, err, entry.File)
+		key, private, err := ParseArmoredKey(string(armored))
+		assert.Nil(t, err, entry.File)
+		assert.Equal(t, entry.Fingerprint, key.Fingerprint())
+		assert.Equal(t, entry.Private, private)
+		assert.Equal(t, entry.UIDs, len(key.Identities))
+		if entry.Passphrase != "" {
+			assert.Nil(t, key.DecryptPrivateKey([]byte(entry.Passphrase)), entry.File)
+		}
+
+		targets := []string{"FuzzParseArmoredKey"}
+		if entry.Private {
+			targets = append(targets, "FuzzImportPrivateKey")
+		}
+		for _, target := range targets {
+			seed, err := ioutil.ReadFile(filepath.Join(dir, "fuzz", target, entry.File))
+			assert.Nil(t, err, entry.File)
+			lines := strings.Split(string(seed), "\n")
+			assert.Equal(t, "go test fuzz v1", lines[0])
+			assert.True(t, strings.HasPrefix(lines[1], "string(") && strings.HasSuffix(lines[1], ")"))
+			unquoted, err := strconv.Unquote(strings.TrimSuffix(strings.TrimPrefix(lines[1], "string("), ")"))
+			assert.Nil(t, err)
+			assert.Equal(t, string(armored), unquoted)
+		}
+	}
+	_, err = os.Stat(filepath.Join(dir, "fuzz", "FuzzImportPrivateKey", "key-0.pub.asc"))
+	assert.True(t, os.IsNotExist(err), "public keys don't seed FuzzImportPrivateKey")
+}
+
+func TestGenerateFuzzCorpusEmpty(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gpgeez")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.Nil(t, GenerateFuzzCorpus(dir, 0))
+	data, err := ioutil.ReadFile(filepath.Join(dir, "manifest.json"))
+	assert.Nil(t, err)
+	assert.Equal(t, "[]", string(data))
+}
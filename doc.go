@@ -0,0 +1,36 @@
+// Package gpgeez is a wrapper around golang.org/x/crypto/openpgp, which makes
+// it easy to create and use OpenPGP keys (RFC 4880,
+// https://tools.ietf.org/html/rfc4880) that work with GnuPG.
+//
+// CreateKey returns a key similar to the one created by gpg --gen-key: a
+// primary key used for signing and certification, and an encryption subkey,
+// with self-signatures which carry the key's expiry and algorithm
+// preferences. The differences with GnuPG's defaults are listed on CreateKey.
+// By default the keys are RSA; DSA/ElGamal and ECDSA keys can be created with
+// Config.KeyType, Config.SubkeyType and Config.Curve.
+//
+// A key goes through the following steps:
+//
+//	config := gpgeez.NewConfig(gpgeez.WithExpiry(365 * 24 * time.Hour))
+//	key, err := gpgeez.CreateKey("Joe", "test key", "joe@example.com", config)
+//	publicKey, err := key.Armor()               // publish this
+//	privateKey, err := key.ArmorPrivate(config) // keep this safe
+//
+//	key, err = gpgeez.ImportPrivateKey(privateKey)
+//	sig, err := key.Sign(message, config)
+//	ciphertext, err := gpgeez.Encrypt(message, []*gpgeez.Key{recipient}, key, config)
+//	err = key.RotateEncryptionSubkey(config)
+//
+// For production use, start from NewConfig (or DefaultConfig), which creates
+// 3072-bit RSA keys expiring after two years, and leave Config.Rand unset so
+// that crypto/rand is used. Set MaxKeyLifetime to enforce an expiry policy.
+// AllowWeakAlgorithms should only be set to talk to legacy systems.
+//
+// Private keys are exported without a passphrase; protect the output of
+// ArmorPrivate accordingly, or split it with SplitPrivateKey.
+//
+// Some useful links:
+// https://tools.ietf.org/html/rfc4880,
+// https://tools.ietf.org/html/rfc6637 (elliptic curves),
+// https://davesteele.github.io/gpg/2014/09/20/anatomy-of-a-gpg-key
+package gpgeez
@@ -0,0 +1,46 @@
+package gpgeez
+
+import (
+	"crypto/dsa"
+	"errors"
+)
+
+const defaultDSABits = 3072
+
+// newDSAKey creates a DSA signing key, sized according to config.DSABits.
+//
+// DSA is only provided for compatibility with legacy systems. Signatures leak
+// the private key if the per-signature nonce is ever reused or biased, the
+// modulus is limited to 3072 bits, and most modern implementations are phasing
+// it out. Prefer Ed25519 (or RSA) whenever the other side supports it.
+func newDSAKey(config *Config) (*dsa.PrivateKey, error) {
+	var sizes dsa.ParameterSizes
+	switch config.dsaBits() {
+	case 1024:
+		sizes = dsa.L1024N160
+	case 2048:
+		sizes = dsa.L2048N256
+	case 3072:
+		sizes = dsa.L3072N256
+	default:
+		return nil, errors.New("gpgeez: DSABits must be 1024, 2048 or 3072")
+	}
+
+	priv := new(dsa.PrivateKey)
+	err := dsa.GenerateParameters(&priv.Parameters, config.Random(), sizes)
+	if err != nil {
+		return nil, err
+	}
+	err = dsa.GenerateKey(priv, config.Random())
+	if err != nil {
+		return nil, err
+	}
+	return priv, nil
+}
+
+func (config *Config) dsaBits() int {
+	if config.DSABits == 0 {
+		return defaultDSABits
+	}
+	return config.DSABits
+}
@@ -0,0 +1,41 @@
+package gpgeez
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
+)
+
+// newECDSAKey creates an ECDSA signing key on config.Curve.
+//
+// Only the NIST curves are supported. secp256k1 is rejected: neither the Go
+// standard library nor the vendored golang.org/x/crypto/openpgp implement it,
+// so supporting it requires vendoring a secp256k1 implementation (such as
+// github.com/btcsuite/btcd/btcec) and an openpgp fork which knows the curve's
+// OID (1.3.132.0.10) when parsing and serializing key packets.
+func newECDSAKey(config *Config) (*ecdsa.PrivateKey, error) {
+	var curve elliptic.Curve
+	switch config.Curve {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	case "secp256k1":
+		return nil, errors.New("gpgeez: secp256k1 requires an openpgp implementation with secp256k1 support")
+	default:
+		return nil, errors.New("gpgeez: unsupported curve " + config.Curve)
+	}
+	return ecdsa.GenerateKey(curve, config.Random())
+}
+
+// isSupportedCurve returns true if the vendored openpgp package can serialize
+// keys on curve.
+func isSupportedCurve(curve elliptic.Curve) bool {
+	switch curve {
+	case elliptic.P256(), elliptic.P384(), elliptic.P521():
+		return true
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package gpgeez
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// Encrypt encrypts the data read from r to each of the recipients. If signer
+// is not nil, the message is also signed. The result is in binary format.
+//
+// The message is protected with a modification detection code; see
+// Config.AEAD.
+//
+// The literal data has the format config.LiteralDataFormat. The vendored
+// openpgp package can't write the UTF-8 format, so text is used instead, with
+// a logged warning. If signer is set, the signature is binary even for text,
+// see Config.LiteralDataFormat.
+func Encrypt(r io.Reader, recipients []*Key, signer *Key, config *Config) ([]byte, error) {
+	if config.AEAD {
+		config.log(slog.LevelWarn, "AEAD encryption is not supported, using MDC")
+	}
+	format := config.literalDataFormat()
+	if format == literalUTF8 {
+		config.log(slog.LevelWarn, "UTF-8 literal data is not supported, using text")
+	}
+	if format != literalBinary {
+		data, err := readLiteralData(r, format)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(data)
+	}
+	hints := &openpgp.FileHints{IsBinary: format == literalBinary}
+	to := make([]*openpgp.Entity, len(recipients))
+	for i, recipient := range recipients {
+		to[i] = &recipient.Entity
+	}
+	var signed *openpgp.Entity
+	if signer != nil {
+		signed = &signer.Entity
+	}
+
+	buf := new(bytes.Buffer)
+	w, err := openpgp.Encrypt(buf, to, signed, hints, config.PacketConfig())
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.Copy(w, r)
+	if err != nil {
+		return nil, err
+	}
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+	config.log(slog.LevelDebug, "message encrypted", "recipients", len(recipients), "signed", signer != nil)
+	return buf.Bytes(), nil
+}
+
+// EncryptToSelf encrypts the data read from r to the key's own encryption
+// subkey, e.g. for backups. It fails if the key could not decrypt the result
+// because it lacks the private part of its encryption subkey.
+func (key *Key) EncryptToSelf(r io.Reader, config *Config) ([]byte, error) {
+	if !key.canDecrypt(config) {
+		return nil, errors.New("gpgeez: key has no private encryption subkey")
+	}
+	return Encrypt(r, []*Key{key}, nil, config)
+}
+
+// Decrypt decrypts a binary message encrypted to key. If the message is
+// signed by key, the signature is checked as well.
+func (key *Key) Decrypt(r io.Reader, config *Config) ([]byte, error) {
+	plaintext, err := key.decrypt(r, config)
+	if err != nil {
+		config.log(slog.LevelWarn, "decryption failed", "fingerprint", key.Fingerprint(), "error", err)
+		return nil, err
+	}
+	config.log(slog.LevelDebug, "message decrypted", "fingerprint", key.Fingerprint())
+	return plaintext, nil
+}
+
+func (key *Key) decrypt(r io.Reader, config *Config) ([]byte, error) {
+	md, err := openpgp.ReadMessage(r, openpgp.EntityList{&key.Entity}, nil, config.PacketConfig())
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := ioutil.ReadAll(md.UnverifiedBody)
+	if err != nil {
+		return nil, err
+	}
+	if md.SignatureError != nil {
+		return nil, md.SignatureError
+	}
+	return plaintext, nil
+}
+
+// canDecrypt returns whether key has an active encryption subkey with its
+// private part, which is what openpgp.Encrypt would pick as recipient.
+func (key *Key) canDecrypt(config *Config) bool {
+	subkey, ok := key.activeEncryptionSubkey(config.now())
+	return ok && subkey.PrivateKey != nil
+}
+
+// EncryptSession encrypts sessionKey to the key's active encryption subkey,
+// see ActiveEncryptionSubkey, and returns the resulting public-key encrypted
+// session key packet, in binary format. The session key is for
+// config.DefaultCipher, and must have the size of its keys. This lets callers
+// manage the session key separately from the data it encrypts.
+func (key *Key) EncryptSession(sessionKey []byte, config *Config) ([]byte, error) {
+	subkey, ok := key.activeEncryptionSubkey(config.now())
+	if !ok {
+		return nil, ErrNoEncryptionSubkey
+	}
+	cipher := config.PacketConfig().Cipher()
+	if len(sessionKey) != cipher.KeySize() {
+		return nil, fmt.Errorf("gpgeez: session key must be %d bytes long", cipher.KeySize())
+	}
+	buf := new(bytes.Buffer)
+	err := packet.SerializeEncryptedKey(buf, subkey.PublicKey, cipher, sessionKey, config.PacketConfig())
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecryptSession decrypts a public-key encrypted session key packet, as
+// returned by EncryptSession, with the private part of the subkey it was
+// encrypted to, and returns the session key.
+func (key *Key) DecryptSession(pkesk []byte, config *Config) ([]byte, error) {
+	p, err := packet.Read(bytes.NewReader(pkesk))
+	if err != nil {
+		return nil, err
+	}
+	ek, ok := p.(*packet.EncryptedKey)
+	if !ok {
+		return nil, errors.New("gpgeez: invalid encrypted session key")
+	}
+	// Revoked subkeys are included, so that old session keys can still be
+	// decrypted.
+	keys := openpgp.EntityList{&key.Entity}.KeysById(ek.KeyId)
+	if ek.KeyId == 0 {
+		// Anonymous recipient, try all the decryption keys.
+		keys = openpgp.EntityList{&key.Entity}.DecryptionKeys()
+	}
+	err = errors.New("gpgeez: session key was not encrypted to this key")
+	for _, k := range keys {
+		var priv *packet.PrivateKey
+		priv, err = checkPrivateKey(k.PrivateKey)
+		if err != nil {
+			continue
+		}
+		err = ek.Decrypt(priv, config.PacketConfig())
+		if err == nil {
+			return ek.Key, nil
+		}
+	}
+	return nil, err
+}
@@ -0,0 +1,73 @@
+package gpgeez
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+func TestEncryptToSelf(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	ciphertext, err := key.EncryptToSelf(strings.NewReader("backup"), &config)
+	assert.Nil(t, err, "EncryptToSelf errored")
+	plaintext, err := key.Decrypt(bytes.NewReader(ciphertext), &config)
+	assert.Nil(t, err, "Decrypt errored")
+	assert.Equal(t, "backup", string(plaintext))
+
+	public, err := ImportPublicKey(mustArmor(t, key))
+	assert.Nil(t, err, "ImportPublicKey errored")
+	_, err = public.EncryptToSelf(strings.NewReader("backup"), &config)
+	assert.NotNil(t, err, "EncryptToSelf should fail without private material")
+}
+
+func mustArmor(t *testing.T, key *Key) string {
+	s, err := key.Armor()
+	assert.Nil(t, err, "Armor errored")
+	return s
+}
+
+func TestEncryptAEADFallback(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, AEAD: true}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	ciphertext, err := Encrypt(strings.NewReader("hello"), []*Key{key}, nil, &config)
+	assert.Nil(t, err, "Encrypt errored")
+	plaintext, err := key.Decrypt(bytes.NewReader(ciphertext), &config)
+	assert.Nil(t, err, "Decrypt errored")
+	assert.Equal(t, "hello", string(plaintext))
+}
+
+func TestEncryptSession(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256", DefaultCipher: packet.CipherAES256}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	sessionKey := bytes.Repeat([]byte{0x42}, 32)
+
+	pkesk, err := key.EncryptSession(sessionKey, &config)
+	assert.Nil(t, err, "EncryptSession errored")
+	decrypted, err := key.DecryptSession(pkesk, &config)
+	assert.Nil(t, err, "DecryptSession errored")
+	assert.Equal(t, sessionKey, decrypted)
+
+	_, err = key.EncryptSession(sessionKey[:16], &config)
+	assert.NotNil(t, err, "AES-256 needs a 32 byte session key")
+
+	public, err := ImportPublicKey(mustArmor(t, key))
+	assert.Nil(t, err, "ImportPublicKey errored")
+	_, err = public.DecryptSession(pkesk, &config)
+	assert.NotNil(t, err, "DecryptSession needs the private key")
+
+	// Session keys encrypted to a retired subkey can still be decrypted.
+	assert.Nil(t, key.RotateEncryptionSubkey(&config))
+	decrypted, err = key.DecryptSession(pkesk, &config)
+	assert.Nil(t, err, "DecryptSession errored")
+	assert.Equal(t, sessionKey, decrypted)
+}
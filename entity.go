@@ -0,0 +1,202 @@
+package gpgeez
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+const defaultRSABits = 2048
+
+// newEntity creates the primary key and encryption subkey described by config
+// and assembles them into an openpgp.Entity, the same way openpgp.NewEntity
+// does for RSA keys.
+func newEntity(name, comment, email string, config *Config) (*openpgp.Entity, error) {
+	primary, err := newPrimaryKey(config)
+	if err != nil {
+		return nil, err
+	}
+	return newEntityWithPrimary(name, comment, email, primary, config)
+}
+
+// newEntityWithPrimary is like newEntity, but uses an existing primary key.
+func newEntityWithPrimary(name, comment, email string, primary *packet.PrivateKey, config *Config) (*openpgp.Entity, error) {
+	subkey, err := newSubkey(config)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &openpgp.Entity{
+		PrimaryKey: &primary.PublicKey,
+		PrivateKey: primary,
+		Identities: make(map[string]*openpgp.Identity),
+	}
+	err = addIdentity(e, UID{name, comment, email}, true, config)
+	if err != nil {
+		return nil, err
+	}
+
+	e.Subkeys = []openpgp.Subkey{newSubkeyBinding(e.PrimaryKey, subkey, config)}
+	return e, nil
+}
+
+// addIdentity adds uid to e, the same way openpgp.NewEntity does. The self
+// signature still needs to be signed.
+func addIdentity(e *openpgp.Entity, uid UID, isPrimaryId bool, config *Config) error {
+	id := packet.NewUserId(uid.Name, uid.Comment, uid.Email)
+	if id == nil {
+		return errors.New("gpgeez: user id field contained invalid characters")
+	}
+	if _, ok := e.Identities[id.Id]; ok {
+		return errors.New("gpgeez: duplicate user id " + id.Id)
+	}
+	e.Identities[id.Id] = &openpgp.Identity{
+		Name:   id.Name,
+		UserId: id,
+		SelfSignature: &packet.Signature{
+			CreationTime: config.now(),
+			SigType:      packet.SigTypePositiveCert,
+			PubKeyAlgo:   e.PrimaryKey.PubKeyAlgo,
+			Hash:         config.hashFor(HashForUIDCertification),
+			IsPrimaryId:  &isPrimaryId,
+			FlagsValid:   true,
+			FlagSign:     true,
+			FlagCertify:  true,
+			IssuerKeyId:  &e.PrimaryKey.KeyId,
+		},
+	}
+	return nil
+}
+
+// newSubkeyBinding returns subkey as an encryption subkey of primary. The
+// binding signature still needs to be signed.
+func newSubkeyBinding(primary *packet.PublicKey, subkey *packet.PrivateKey, config *Config) openpgp.Subkey {
+	// ElGamal subkeys are flagged as encrypt-only (0x04), like GnuPG does.
+	encryptStorage := subkey.PubKeyAlgo != packet.PubKeyAlgoElGamal
+	subkey.IsSubkey = true
+	subkey.PublicKey.IsSubkey = true
+	return openpgp.Subkey{
+		PublicKey:  &subkey.PublicKey,
+		PrivateKey: subkey,
+		Sig: &packet.Signature{
+			CreationTime:              config.now(),
+			SigType:                   packet.SigTypeSubkeyBinding,
+			PubKeyAlgo:                primary.PubKeyAlgo,
+			Hash:                      config.hashFor(HashForSubkeyBinding),
+			FlagsValid:                true,
+			FlagEncryptStorage:        encryptStorage,
+			FlagEncryptCommunications: true,
+			IssuerKeyId:               &primary.KeyId,
+		},
+	}
+}
+
+// newPrimaryKey creates a signing key of type config.KeyType.
+func newPrimaryKey(config *Config) (*packet.PrivateKey, error) {
+	if config.Curve != "" {
+		if config.KeyType != "" && config.KeyType != "ECDSA" {
+			return nil, errors.New("gpgeez: Curve cannot be used with key type " + config.KeyType)
+		}
+		priv, err := newECDSAKey(config)
+		if err != nil {
+			return nil, err
+		}
+		return packet.NewECDSAPrivateKey(config.now(), priv), nil
+	}
+	switch config.KeyType {
+	case "", "RSA":
+		priv, err := rsa.GenerateKey(config.Random(), config.rsaBits())
+		if err != nil {
+			return nil, err
+		}
+		return packet.NewRSAPrivateKey(config.now(), priv), nil
+	case "DSA":
+		priv, err := newDSAKey(config)
+		if err != nil {
+			return nil, err
+		}
+		return packet.NewDSAPrivateKey(config.now(), priv), nil
+	}
+	return nil, errors.New("gpgeez: unsupported key type " + config.KeyType)
+}
+
+// newSubkey creates an encryption key of type config.SubkeyType. If
+// SubkeyType is empty, RSA and ECDSA primary keys get an RSA subkey and DSA
+// primary keys get an ElGamal subkey. ElGamal subkeys have the size of the
+// primary key, capped to 3072 bits like gpg does.
+func newSubkey(config *Config) (*packet.PrivateKey, error) {
+	switch config.subkeyType() {
+	case "RSA":
+		priv, err := rsa.GenerateKey(config.Random(), config.rsaBits())
+		if err != nil {
+			return nil, err
+		}
+		return packet.NewRSAPrivateKey(config.now(), priv), nil
+	case "ElGamal":
+		bits := config.rsaBits()
+		if config.KeyType == "DSA" {
+			bits = config.dsaBits()
+		}
+		if bits > maxElGamalBits {
+			bits = maxElGamalBits
+		}
+		priv, err := newElGamalKey(config.Random(), bits)
+		if err != nil {
+			return nil, err
+		}
+		return packet.NewElGamalPrivateKey(config.now(), priv), nil
+	}
+	return nil, errors.New("gpgeez: unsupported subkey type " + config.SubkeyType)
+}
+
+// importPrivateKey turns an existing RSA or ECDSA private key into the
+// primary key of a new entity, then self-signs it like CreateKey does.
+func importPrivateKey(priv interface{}, name, comment, email string, config *Config) (*Key, error) {
+	err := config.validate()
+	if err != nil {
+		return nil, err
+	}
+	expiry, err := config.expiry()
+	if err != nil {
+		return nil, err
+	}
+
+	var primary *packet.PrivateKey
+	switch k := priv.(type) {
+	case *rsa.PrivateKey:
+		primary = packet.NewRSAPrivateKey(config.now(), k)
+	case *ecdsa.PrivateKey:
+		if !isSupportedCurve(k.Curve) {
+			return nil, errors.New("gpgeez: unsupported elliptic curve")
+		}
+		primary = packet.NewECDSAPrivateKey(config.now(), k)
+	default:
+		return nil, errors.New("gpgeez: unsupported private key type")
+	}
+
+	e, err := newEntityWithPrimary(name, comment, email, primary, config)
+	if err != nil {
+		return nil, err
+	}
+	return selfSign(e, expiry, config)
+}
+
+func (config *Config) rsaBits() int {
+	if bits := config.PacketConfig().RSABits; bits != 0 {
+		return bits
+	}
+	return defaultRSABits
+}
+
+func (config *Config) subkeyType() string {
+	if config.SubkeyType != "" {
+		return config.SubkeyType
+	}
+	if config.KeyType == "DSA" {
+		return "ElGamal"
+	}
+	return "RSA"
+}
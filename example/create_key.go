@@ -30,5 +30,10 @@ func main() {
 	fmt.Printf("%s\n", output)
 
 	ioutil.WriteFile("pub.gpg", key.Keyring(), 0666)
-	ioutil.WriteFile("priv.gpg", key.Secring(&config), 0666)
+	secring, err := key.Secring(&config)
+	if err != nil {
+		fmt.Printf("Something went wrong: %v", err)
+		return
+	}
+	ioutil.WriteFile("priv.gpg", secring, 0666)
 }
@@ -0,0 +1,84 @@
+package gpgeez_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alokmenghrajani/gpgeez"
+)
+
+func ExampleEncrypt() {
+	config := gpgeez.Config{Expiry: 365 * 24 * time.Hour}
+	key, err := gpgeez.CreateKey("Joe", "test key", "joe@example.com", &config)
+	if err != nil {
+		fmt.Printf("Something went wrong: %v", err)
+		return
+	}
+
+	// Only the public key is needed to encrypt.
+	publicKey, err := key.Armor()
+	if err != nil {
+		fmt.Printf("Something went wrong: %v", err)
+		return
+	}
+	recipient, err := gpgeez.ImportPublicKey(publicKey)
+	if err != nil {
+		fmt.Printf("Something went wrong: %v", err)
+		return
+	}
+	ciphertext, err := gpgeez.Encrypt(strings.NewReader("hello world"), []*gpgeez.Key{recipient}, nil, &config)
+	if err != nil {
+		fmt.Printf("Something went wrong: %v", err)
+		return
+	}
+
+	// The private key is needed to decrypt.
+	plaintext, err := key.Decrypt(bytes.NewReader(ciphertext), &config)
+	if err != nil {
+		fmt.Printf("Something went wrong: %v", err)
+		return
+	}
+	fmt.Printf("%s\n", plaintext)
+	// Output: hello world
+}
+
+func ExampleKey_Sign() {
+	config := gpgeez.Config{Expiry: 365 * 24 * time.Hour}
+	key, err := gpgeez.CreateKey("Joe", "test key", "joe@example.com", &config)
+	if err != nil {
+		fmt.Printf("Something went wrong: %v", err)
+		return
+	}
+
+	sig, err := key.Sign(strings.NewReader("hello world"), &config)
+	if err != nil {
+		fmt.Printf("Something went wrong: %v", err)
+		return
+	}
+
+	err = key.Verify(strings.NewReader("hello world"), sig, &config)
+	fmt.Printf("valid: %v\n", err == nil)
+	err = key.Verify(strings.NewReader("goodbye world"), sig, &config)
+	fmt.Printf("tampered: %v\n", err == nil)
+	// Output:
+	// valid: true
+	// tampered: false
+}
+
+func Example() {
+	config := gpgeez.NewConfig(gpgeez.WithExpiry(365 * 24 * time.Hour))
+	key, err := gpgeez.CreateKey("Joe", "test key", "joe@example.com", config)
+	if err != nil {
+		fmt.Printf("Something went wrong: %v", err)
+		return
+	}
+	publicKey, err := key.Armor()
+	if err != nil {
+		fmt.Printf("Something went wrong: %v", err)
+		return
+	}
+	fmt.Println(strings.SplitN(publicKey, "\n", 2)[0])
+	// Output: -----BEGIN PGP PUBLIC KEY BLOCK-----
+}
@@ -0,0 +1,57 @@
+package gpgeez
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/binary"
+	"errors"
+
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// FingerprintV5 returns the fingerprint the primary key would have as a v5
+// key, see https://tools.ietf.org/html/draft-ietf-openpgp-rfc4880bis-10#section-12.2
+// It is the SHA-256 hash of the key packet in the v5 format, which has the
+// same key material as the v4 one, preceded by its length.
+//
+// Keys using the deprecated RSA encrypt-only or sign-only algorithms can't be
+// v5 keys, and return an error.
+func (key *Key) FingerprintV5() ([]byte, error) {
+	return fingerprintV5(key.PrimaryKey)
+}
+
+func fingerprintV5(pk *packet.PublicKey) ([]byte, error) {
+	switch pk.PubKeyAlgo {
+	case packet.PubKeyAlgoRSAEncryptOnly, packet.PubKeyAlgoRSASignOnly:
+		return nil, errors.New("gpgeez: deprecated RSA algorithms can't be used in v5 keys")
+	}
+	buf := new(bytes.Buffer)
+	err := pk.Serialize(buf)
+	if err != nil {
+		return nil, err
+	}
+	body, err := packetBody(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	// A v4 key packet is the version, the creation time, the algorithm and
+	// the key material.
+	if len(body) < 6 || body[0] != 4 {
+		return nil, errors.New("gpgeez: only v4 keys can be converted to v5")
+	}
+	material := body[6:]
+
+	v5 := make([]byte, 10, 10+len(material))
+	v5[0] = 5
+	copy(v5[1:6], body[1:6])
+	binary.BigEndian.PutUint32(v5[6:], uint32(len(material)))
+	v5 = append(v5, material...)
+
+	h := crypto.SHA256.New()
+	var header [5]byte
+	header[0] = 0x9a
+	binary.BigEndian.PutUint32(header[1:], uint32(len(v5)))
+	h.Write(header[:])
+	h.Write(v5)
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,29 @@
+package gpgeez
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprintV5(t *testing.T) {
+	config := Config{
+		Rand:         NewFakeRand(),
+		CreationTime: time.Unix(1262304000, 0),
+		KeyType:      "DSA",
+		DSABits:      1024,
+	}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	assert.Equal(t, "40F7EF92A33E2ED508588827529F1947C201A313", key.Fingerprint())
+
+	fp, err := key.FingerprintV5()
+	assert.Nil(t, err, "FingerprintV5 errored")
+	assert.Equal(t, "1762648e1be729cc665ed5d5a1efc07faf688afe370ab7f2503089c478af2c6b", hex.EncodeToString(fp))
+
+	subkey, err := fingerprintV5(key.Subkeys[0].PublicKey)
+	assert.Nil(t, err, "fingerprintV5 errored")
+	assert.NotEqual(t, fp, subkey)
+}
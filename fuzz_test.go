@@ -0,0 +1,62 @@
+//go:build go1.18
+// +build go1.18
+
+package gpgeez
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func fuzzKey(f *testing.F) (*Key, *Config) {
+	config := &Config{Expiry: 365 * 24 * time.Hour}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", config)
+	if err != nil {
+		f.Fatal(err)
+	}
+	return key, config
+}
+
+func FuzzParseArmoredKey(f *testing.F) {
+	key, config := fuzzKey(f)
+	public, err := key.Armor()
+	if err != nil {
+		f.Fatal(err)
+	}
+	private, err := key.ArmorPrivate(config)
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(public)
+	f.Add(private)
+	f.Fuzz(func(t *testing.T, armored string) {
+		ParseArmoredKey(armored)
+	})
+}
+
+func FuzzImportPrivateKey(f *testing.F) {
+	key, config := fuzzKey(f)
+	private, err := key.ArmorPrivate(config)
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(private)
+	f.Add(strings.Replace(private, "PRIVATE", "PUBLIC", -1))
+	f.Fuzz(func(t *testing.T, armored string) {
+		ImportPrivateKey(armored)
+	})
+}
+
+func FuzzDecrypt(f *testing.F) {
+	key, config := fuzzKey(f)
+	ciphertext, err := Encrypt(strings.NewReader("hello world"), []*Key{key}, key, config)
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(ciphertext)
+	f.Fuzz(func(t *testing.T, ciphertext []byte) {
+		key.Decrypt(bytes.NewReader(ciphertext), config)
+	})
+}
@@ -1,20 +1,161 @@
-// Package gpgeez is a wrapper around golang.org/x/crypto/openpgp
 package gpgeez
 
 import (
 	"bytes"
+	"context"
+	"crypto"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/url"
+	"strings"
 	"time"
 
 	"golang.org/x/crypto/openpgp"
 	"golang.org/x/crypto/openpgp/armor"
 	"golang.org/x/crypto/openpgp/packet"
+	"golang.org/x/crypto/openpgp/s2k"
 )
 
 // Config for generating keys.
+//
+// The commonly used fields of packet.Config are repeated at the top level.
+// When set, they take precedence over the ones in the embedded packet.Config,
+// which is kept for compatibility. Use PacketConfig to get the combined
+// packet.Config.
 type Config struct {
 	packet.Config
+	// Rand provides the source of entropy for keys, signatures and session
+	// keys. If nil, crypto/rand is used.
+	//
+	// Warning: Rand must be cryptographically secure in production. Anyone
+	// who can predict its output can recover the private keys created with
+	// it. Only substitute a deterministic reader in tests.
+	Rand io.Reader
+	// DefaultHash is the hash function used for signatures. If zero, SHA-256
+	// is used.
+	DefaultHash crypto.Hash
+	// HashAlgorithmFor overrides DefaultHash for some operations:
+	// HashForUIDCertification for the certifications of user ids, including
+	// the self-signatures made by CreateKey, HashForSubkeyBinding for subkey
+	// binding signatures, and HashForDataSignature for Sign and MultiSign.
+	HashAlgorithmFor map[string]HashAlgorithm
+	// PreferredHash, when set, replaces the hash preferences written in the
+	// self-signatures, most preferred first. It must include the hash
+	// functions used for signing, see ValidateConfig.
+	PreferredHash []HashAlgorithm
+	// DefaultCipher is the cipher used for encryption. If zero, AES-128 is
+	// used.
+	DefaultCipher packet.CipherFunction
+	// DefaultCompressionAlgo is the compression algorithm applied before
+	// encryption. If zero, no compression is done.
+	DefaultCompressionAlgo packet.CompressionAlgo
+	// CompressionConfig configures the compression settings.
+	CompressionConfig *packet.CompressionConfig
+	// S2KCount is the number of iterations used to hash passphrases, see
+	// packet.Config.
+	S2KCount int
+	// RSABits is the size of new RSA keys. If zero, 2048 is used.
+	RSABits int
+	// Now returns the current time, which is used as the creation time of new
+	// keys and signatures. If nil, time.Now is used. Tests can set it to a
+	// fixed time to get deterministic keys.
+	Now func() time.Time
+	// CreationTime, when set, is used instead of Now as the creation time of
+	// the keys made by CreateKey and of their self-signatures. Since the
+	// fingerprint covers the creation time, this lets keys migrated from
+	// another system keep their fingerprint. Expiry counts from CreationTime,
+	// so a key created in the past may already be expired.
+	CreationTime time.Time
 	// Expiry is the duration that the generated key will be valid for.
 	Expiry time.Duration
+	// MaxKeyLifetime, when non-zero, is an upper bound on Expiry. By default,
+	// CreateKey returns an error if Expiry exceeds it. If ClampExpiry is set,
+	// Expiry is instead reduced to MaxKeyLifetime and a warning is logged.
+	MaxKeyLifetime time.Duration
+	ClampExpiry    bool
+	// KeyType is the algorithm of the primary key: "RSA" or "DSA". If empty,
+	// RSA is used. DSA keys get an ElGamal encryption subkey.
+	KeyType string
+	// DSABits is the size of the DSA modulus when KeyType is "DSA". Valid
+	// values are 1024, 2048 and 3072. If zero, 3072 is used.
+	DSABits int
+	// SubkeyType is the algorithm of the encryption subkey: "RSA" or
+	// "ElGamal". If empty, RSA and ECDSA keys get an RSA subkey and DSA keys
+	// get an ElGamal subkey. ElGamal subkeys are at most 3072 bits long, even
+	// when RSABits is larger.
+	SubkeyType string
+	// Curve, when set, creates an ECDSA primary key on the named curve:
+	// "P-256", "P-384" or "P-521". "secp256k1" is recognized but currently
+	// returns an error, since the vendored openpgp package has no support for
+	// it.
+	Curve string
+	// PreferredKeyserver, when set, is the URL of the keyserver where
+	// updates to the key should be fetched from. It is written in each
+	// identity's self-signature, see
+	// https://tools.ietf.org/html/rfc4880#section-5.2.3.18
+	PreferredKeyserver string
+	// AllowWeakAlgorithms makes Verify and ValidateKey accept MD5 and SHA-1
+	// signatures, and keys weaker than MinimumKeyStrength (e.g. RSA-1024),
+	// with a logged warning. Only set it to talk to legacy systems.
+	AllowWeakAlgorithms bool
+	// SignatureExpiry, when non-zero, is how long the self-signatures and
+	// subkey binding signatures stay valid, see
+	// https://tools.ietf.org/html/rfc4880#section-5.2.3.10
+	// Unlike Expiry, it applies to the signatures rather than to the key, so
+	// a long-lived key can be re-certified periodically.
+	SignatureExpiry time.Duration
+	// ClockSkewTolerance is how far in the future the creation time of a
+	// signature may be for Verify to accept it, to allow for clocks which
+	// drift between the signing and verifying machines. If zero, 5 minutes is
+	// used.
+	ClockSkewTolerance time.Duration
+	// EmitVersionHeader adds a "Version: <VersionString>" header to the
+	// armored output of the methods which take a config, like GnuPG does
+	// without --no-emit-version. VersionString defaults to "gpgeez".
+	EmitVersionHeader bool
+	VersionString     string
+	// ArmorLineWidth, when non-zero, is the length of the base64 lines in
+	// the armored output of the methods which take a config. It defaults to
+	// 64, and can be at most 76. Some legacy systems reject lines longer
+	// than 64 characters, others expect 76. Armor and WriteArmoredKeyRing
+	// take no config, so public keys are always wrapped at 64 characters.
+	ArmorLineWidth int
+	// LiteralDataFormat is the format of the literal data packets written
+	// by SignMessage and Encrypt: 'b' for binary, 't' for text or 'u' for
+	// UTF-8 text, see https://tools.ietf.org/html/rfc4880#section-5.9
+	// The line endings of text are converted to CRLF before signing, as
+	// required for signed email. SignMessage then makes a text signature;
+	// the one embedded by Encrypt stays binary, since the vendored openpgp
+	// package always signs in binary mode. It is made over the converted
+	// data, so it still verifies. If zero, 'b' is used.
+	LiteralDataFormat byte
+	// AEAD requests AEAD encrypted data packets (RFC 4880bis) from Encrypt.
+	// The vendored openpgp package doesn't support them yet, so Encrypt logs
+	// a warning and falls back to MDC-protected encryption. AEAD support is
+	// not advertised in the features subpacket, since this package couldn't
+	// decrypt such messages.
+	AEAD bool
+	// NonExportable marks the certifications made by CertifyKey and
+	// TrustCertifyUID as local, like gpg --lsign-key does, see
+	// https://tools.ietf.org/html/rfc4880#section-5.2.3.11
+	// Local certifications are left out by Armor, Keyring, WriteKeyRing and
+	// Canonicalize, so they don't reach keyservers.
+	NonExportable bool
+	// TrustRegex, when set, limits the trust signatures made by
+	// TrustCertifyUID to the user ids matching it, e.g.
+	// "<[^>]+[@.]example\.com>$", see
+	// https://tools.ietf.org/html/rfc4880#section-5.2.3.14
+	// It must compile with the regexp package.
+	TrustRegex string
+	// Logger, when set, receives structured logs of key creation, signing,
+	// verification, encryption and decryption, as well as the warnings about
+	// settings which can't be honored. Key ids and fingerprints are logged,
+	// but never key material or plaintext. If nil, nothing is logged.
+	Logger *slog.Logger
 }
 
 // Key represents an OpenPGP key.
@@ -22,6 +163,9 @@ type Key struct {
 	openpgp.Entity
 }
 
+// ErrNilKey is returned when a method is called on a nil *Key.
+var ErrNilKey = errors.New("gpgeez: key is nil")
+
 // Values from https://tools.ietf.org/html/rfc4880#section-9
 const (
 	md5       = 1
@@ -49,6 +193,13 @@ const (
 //
 // • Issuer key ID is hashed subpkt instead of subpkt, and contains a primary user ID sub packet.
 //
+// Setting config.KeyType to "DSA" creates a DSA primary key with an ElGamal
+// subkey instead. config.SubkeyType can be set to "ElGamal" to pair an RSA
+// primary key with an ElGamal subkey, which some legacy implementations
+// require. DSA is weak by modern standards (a single biased or reused
+// nonce reveals the private key) and should only be used to talk to legacy
+// systems. Ed25519 is the preferred alternative.
+//
 // You can see these differences for yourself by comparing the output of:
 //  go run example/create_key.go | gpg --homedir /tmp --list-packets
 // with:
@@ -63,83 +214,454 @@ const (
 // https://davesteele.github.io/gpg/2014/09/20/anatomy-of-a-gpg-key,
 // https://github.com/golang/go/issues/12153
 func CreateKey(name, comment, email string, config *Config) (*Key, error) {
+	return CreateKeyMultiUID([]UID{{name, comment, email}}, config)
+}
+
+// UID is a user id, which is formatted as "Name (Comment) <Email>".
+type UID struct {
+	Name, Comment, Email string
+}
+
+// CreateKeyMultiUID is like CreateKey, but creates a key with several user
+// ids, e.g. for each of the owner's email addresses. Each one gets its own
+// self-signature with the same algorithm preferences, and the first one is
+// marked as the primary user id.
+func CreateKeyMultiUID(uids []UID, config *Config) (*Key, error) {
+	if len(uids) == 0 {
+		return nil, errors.New("gpgeez: at least one user id is required")
+	}
+	err := config.validate()
+	if err != nil {
+		return nil, err
+	}
+	expiry, err := config.expiry()
+	if err != nil {
+		return nil, err
+	}
+	if !config.CreationTime.IsZero() {
+		c := *config
+		c.Now = func() time.Time { return config.CreationTime }
+		config = &c
+	}
+
 	// Create the key
-	key, err := openpgp.NewEntity(name, comment, email, &config.Config)
+	var key *openpgp.Entity
+	first := uids[0]
+	if config.KeyType == "" && config.SubkeyType == "" && config.Curve == "" {
+		key, err = openpgp.NewEntity(first.Name, first.Comment, first.Email, config.PacketConfig())
+	} else {
+		key, err = newEntity(first.Name, first.Comment, first.Email, config)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gpgeez: creating key: %w", err)
+	}
+	for _, uid := range uids[1:] {
+		err = addIdentity(key, uid, false, config)
+		if err != nil {
+			return nil, fmt.Errorf("gpgeez: creating key: %w", err)
+		}
+	}
+	r, err := selfSign(key, expiry, config)
+	if err != nil {
+		return nil, err
+	}
+	config.log(slog.LevelInfo, "key created",
+		"fingerprint", r.Fingerprint(),
+		"algorithm", algorithmName(r.PrimaryKey.PubKeyAlgo),
+		"bits", keyBits(r.PrimaryKey))
+	return r, nil
+}
+
+// selfSign sets the expiry and algorithm preferences of a freshly created
+// entity, and self-signs its identities and subkeys.
+func selfSign(e *openpgp.Entity, expiry time.Duration, config *Config) (*Key, error) {
+	// Set expiry. Self-sign the identities with the algorithm preferences.
+	lifetime := keyLifetime(expiry)
+	for _, id := range e.Identities {
+		id.SelfSignature.Hash = config.hashFor(HashForUIDCertification)
+		id.SelfSignature.KeyLifetimeSecs = lifetime
+		id.SelfSignature.SigLifetimeSecs = config.signatureLifetime()
+	}
+	key := &Key{*e}
+	err := synchronizePreferences(key, config)
 	if err != nil {
 		return nil, err
 	}
 
-	// Set expiry and algorithms. Self-sign the identity.
-	dur := uint32(config.Expiry.Seconds())
+	// Self-sign the Subkeys
+	for _, subkey := range key.Subkeys {
+		subkey.Sig.Hash = config.hashFor(HashForSubkeyBinding)
+		subkey.Sig.KeyLifetimeSecs = lifetime
+		subkey.Sig.SigLifetimeSecs = config.signatureLifetime()
+		err := subkey.Sig.SignKey(subkey.PublicKey, key.PrivateKey, config.PacketConfig())
+		if err != nil {
+			return nil, fmt.Errorf("gpgeez: signing subkey %s: %w", subkey.PublicKey.KeyIdString(), err)
+		}
+	}
+	return key, nil
+}
+
+// synchronizePreferences sets the same algorithm preferences, from config, on
+// the self-signatures of all the identities of key, and signs them again.
+// Implementations pick the preferences from any of the self-signatures, so
+// they must not differ.
+func synchronizePreferences(key *Key, config *Config) error {
 	for _, id := range key.Identities {
-		id.SelfSignature.KeyLifetimeSecs = &dur
+		config.setPreferences(id.SelfSignature)
+
+		extra := config.selfSignatureSubpackets()
+		if len(extra) == 0 {
+			err := id.SelfSignature.SignUserId(id.UserId.Id, key.PrimaryKey, key.PrivateKey, config.PacketConfig())
+			if err != nil {
+				return fmt.Errorf("gpgeez: signing uid %q: %w", id.UserId.Id, err)
+			}
+			continue
+		}
+		h, err := hashUserId(id.UserId.Id, key.PrimaryKey, id.SelfSignature)
+		if err != nil {
+			return fmt.Errorf("gpgeez: signing uid %q: %w", id.UserId.Id, err)
+		}
+		id.SelfSignature, err = signWithSubpackets(id.SelfSignature, h, key.PrivateKey, extra, config)
+		if err != nil {
+			return fmt.Errorf("gpgeez: signing uid %q: %w", id.UserId.Id, err)
+		}
+	}
+	return nil
+}
+
+// setPreferences sets the algorithm preferences of a self-signature.
+func (config *Config) setPreferences(sig *packet.Signature) {
+	sig.PreferredSymmetric = []uint8{
+		uint8(packet.CipherAES256),
+		uint8(packet.CipherAES192),
+		uint8(packet.CipherAES128),
+		uint8(packet.CipherCAST5),
+		uint8(packet.Cipher3DES),
+	}
 
-		id.SelfSignature.PreferredSymmetric = []uint8{
-			uint8(packet.CipherAES256),
-			uint8(packet.CipherAES192),
-			uint8(packet.CipherAES128),
-			uint8(packet.CipherCAST5),
-			uint8(packet.Cipher3DES),
+	// GnuPG's order, with SHA-1 last, so that it is only used with
+	// implementations which don't support anything else.
+	sig.PreferredHash = []uint8{
+		sha256,
+		sha512,
+		sha384,
+		sha224,
+		sha1,
+	}
+	if len(config.PreferredHash) > 0 {
+		sig.PreferredHash = make([]uint8, len(config.PreferredHash))
+		for i, h := range config.PreferredHash {
+			sig.PreferredHash[i] = uint8(h)
 		}
+	}
+
+	sig.PreferredCompression = []uint8{
+		uint8(packet.CompressionZLIB),
+		uint8(packet.CompressionZIP),
+	}
+}
+
+// PacketConfig returns the packet.Config to pass to the openpgp package: the
+// embedded packet.Config, overridden by the top-level fields which are set.
+func (config *Config) PacketConfig() *packet.Config {
+	c := config.Config
+	if config.Rand != nil {
+		c.Rand = config.Rand
+	}
+	if config.DefaultHash != 0 {
+		c.DefaultHash = config.DefaultHash
+	}
+	if config.DefaultCipher != 0 {
+		c.DefaultCipher = config.DefaultCipher
+	}
+	if config.DefaultCompressionAlgo != 0 {
+		c.DefaultCompressionAlgo = config.DefaultCompressionAlgo
+	}
+	if config.CompressionConfig != nil {
+		c.CompressionConfig = config.CompressionConfig
+	}
+	if config.S2KCount != 0 {
+		c.S2KCount = config.S2KCount
+	}
+	if config.RSABits != 0 {
+		c.RSABits = config.RSABits
+	}
+	if config.Now != nil {
+		c.Time = config.Now
+	}
+	return &c
+}
+
+// Random returns the source of entropy, see PacketConfig.
+func (config *Config) Random() io.Reader {
+	return config.PacketConfig().Random()
+}
+
+// Hash returns the hash function used for signatures, see PacketConfig.
+func (config *Config) Hash() crypto.Hash {
+	return config.PacketConfig().Hash()
+}
+
+// Operation names for Config.HashAlgorithmFor.
+const (
+	HashForUIDCertification = "uid_certification"
+	HashForSubkeyBinding    = "subkey_binding"
+	HashForDataSignature    = "data_signature"
+)
+
+// hashFor returns the hash function for operation, see
+// Config.HashAlgorithmFor.
+func (config *Config) hashFor(operation string) crypto.Hash {
+	if h, ok := config.HashAlgorithmFor[operation]; ok {
+		return h.Hash()
+	}
+	return config.Hash()
+}
+
+// now returns the current time, see Config.Now.
+func (config *Config) now() time.Time {
+	return config.PacketConfig().Now()
+}
+
+// Cipher returns the cipher used for encryption, see PacketConfig.
+func (config *Config) Cipher() packet.CipherFunction {
+	return config.PacketConfig().Cipher()
+}
+
+// Compression returns the compression algorithm, see PacketConfig.
+func (config *Config) Compression() packet.CompressionAlgo {
+	return config.PacketConfig().Compression()
+}
 
-		id.SelfSignature.PreferredHash = []uint8{
-			sha256,
-			sha1,
-			sha384,
-			sha512,
-			sha224,
+// ValidateConfig checks config for invalid or conflicting settings, such as a
+// signing hash (DefaultHash or an entry of HashAlgorithmFor) missing from
+// PreferredHash. CreateKey does the same checks.
+func ValidateConfig(config *Config) error {
+	return config.validate()
+}
+
+// validate checks the fields of config which CreateKey can't check on its own.
+func (config *Config) validate() error {
+	if config.PreferredKeyserver != "" {
+		u, err := url.Parse(config.PreferredKeyserver)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return errors.New("gpgeez: PreferredKeyserver is not a valid URL")
+		}
+	}
+	if err := checkArmorLineWidth(config.ArmorLineWidth); err != nil {
+		return err
+	}
+	// The openpgp package panics on hash functions OpenPGP has no id for.
+	if _, ok := s2k.HashToHashId(config.Hash()); !ok || !config.Hash().Available() {
+		return fmt.Errorf("gpgeez: hash function %v is not supported", config.Hash())
+	}
+	switch config.LiteralDataFormat {
+	case 0, literalBinary, literalText, literalUTF8:
+	default:
+		return fmt.Errorf("gpgeez: unknown literal data format %q", config.LiteralDataFormat)
+	}
+	for operation, h := range config.HashAlgorithmFor {
+		switch operation {
+		case HashForUIDCertification, HashForSubkeyBinding, HashForDataSignature:
+		default:
+			return fmt.Errorf("gpgeez: unknown operation %q in HashAlgorithmFor", operation)
 		}
+		if !h.Hash().Available() {
+			return fmt.Errorf("gpgeez: hash function %v is not supported", h)
+		}
+	}
+	return config.checkPreferredHash()
+}
 
-		id.SelfSignature.PreferredCompression = []uint8{
-			uint8(packet.CompressionZLIB),
-			uint8(packet.CompressionZIP),
+// checkPreferredHash checks that the hash functions used for signing are in
+// config.PreferredHash, if it is set. Otherwise the key would make signatures
+// with a hash it tells others not to use.
+func (config *Config) checkPreferredHash() error {
+	if len(config.PreferredHash) == 0 {
+		return nil
+	}
+	for _, h := range config.PreferredHash {
+		if !h.Hash().Available() {
+			return fmt.Errorf("gpgeez: hash function %v in PreferredHash is not supported", h)
+		}
+	}
+	names := []string{"DefaultHash"}
+	hashes := []crypto.Hash{config.Hash()}
+	for _, operation := range []string{HashForUIDCertification, HashForSubkeyBinding, HashForDataSignature} {
+		if h, ok := config.HashAlgorithmFor[operation]; ok {
+			names = append(names, "HashAlgorithmFor["+operation+"]")
+			hashes = append(hashes, h.Hash())
 		}
+	}
+	for i, hash := range hashes {
+		found := false
+		for _, h := range config.PreferredHash {
+			found = found || h.Hash() == hash
+		}
+		if !found {
+			return fmt.Errorf("gpgeez: %s is %v, which is not in PreferredHash %v: signatures would use a hash the key doesn't advertise", names[i], hash, config.PreferredHash)
+		}
+	}
+	return nil
+}
 
-		err := id.SelfSignature.SignUserId(id.UserId.Id, key.PrimaryKey, key.PrivateKey, &config.Config)
-		if err != nil {
-			return nil, err
+// selfSignatureSubpackets returns the subpackets which CreateKey adds to each
+// identity's self-signature, beyond the ones the openpgp package writes.
+func (config *Config) selfSignatureSubpackets() []subpacket {
+	var r []subpacket
+	if config.PreferredKeyserver != "" {
+		r = append(r, subpacket{subpacketPreferredKeyserver, false, []byte(config.PreferredKeyserver)})
+	}
+	return r
+}
+
+// log logs msg at level to config.Logger, if it is set.
+func (config *Config) log(level slog.Level, msg string, args ...interface{}) {
+	if config == nil || config.Logger == nil {
+		return
+	}
+	config.Logger.Log(context.Background(), level, msg, args...)
+}
+
+// armorHeaders returns the headers of armored blocks.
+func (config *Config) armorHeaders() map[string]string {
+	headers := make(map[string]string)
+	if config != nil && config.EmitVersionHeader {
+		headers["Version"] = config.VersionString
+		if config.VersionString == "" {
+			headers["Version"] = "gpgeez"
 		}
 	}
+	return headers
+}
 
-	// Self-sign the Subkeys
-	for _, subkey := range key.Subkeys {
-		subkey.Sig.KeyLifetimeSecs = &dur
-		err := subkey.Sig.SignKey(subkey.PublicKey, key.PrivateKey, &config.Config)
-		if err != nil {
-			return nil, err
+// signatureLifetime returns config.SignatureExpiry in seconds, or nil if it
+// isn't set.
+func (config *Config) signatureLifetime() *uint32 {
+	if config.SignatureExpiry == 0 {
+		return nil
+	}
+	secs := durationSeconds(config.SignatureExpiry)
+	return &secs
+}
+
+// clockSkewTolerance returns config.ClockSkewTolerance, or its default.
+func (config *Config) clockSkewTolerance() time.Duration {
+	if config.ClockSkewTolerance == 0 {
+		return defaultClockSkewTolerance
+	}
+	return config.ClockSkewTolerance
+}
+
+// expiry returns config.Expiry, after enforcing config.MaxKeyLifetime. A zero
+// Expiry (a key which never expires) always exceeds MaxKeyLifetime.
+func (config *Config) expiry() (time.Duration, error) {
+	max := config.MaxKeyLifetime
+	if max == 0 || (config.Expiry != 0 && config.Expiry <= max) {
+		return config.Expiry, nil
+	}
+	if !config.ClampExpiry {
+		return 0, fmt.Errorf("gpgeez: expiry %v exceeds the maximum key lifetime of %v", config.Expiry, max)
+	}
+	config.log(slog.LevelWarn, "clamping expiry to the maximum key lifetime", "expiry", config.Expiry, "max", max)
+	return max, nil
+}
+
+// ExpirySeconds returns config.Expiry as written in the key lifetime
+// subpacket: truncated to whole seconds, like GnuPG does, and capped to the
+// largest value the subpacket can hold, about 136 years. It doesn't take
+// MaxKeyLifetime into account.
+func (config *Config) ExpirySeconds() uint32 {
+	return durationSeconds(config.Expiry)
+}
+
+// durationSeconds returns d in whole seconds, as used by the lifetime
+// subpackets, see ExpirySeconds.
+func durationSeconds(d time.Duration) uint32 {
+	switch s := d / time.Second; {
+	case s < 0:
+		return 0
+	case s > math.MaxUint32:
+		return math.MaxUint32
+	default:
+		return uint32(s)
+	}
+}
+
+// keyLifetime returns the key lifetime of a key valid for expiry. It is nil
+// for keys which never expire: the openpgp package considers a lifetime of
+// zero to expire at the key's creation time, until it is serialized.
+func keyLifetime(expiry time.Duration) *uint32 {
+	if expiry <= 0 {
+		return nil
+	}
+	secs := durationSeconds(expiry)
+	if secs == 0 {
+		secs = 1
+	}
+	return &secs
+}
+
+// primaryIdentity returns the identity marked as primary. If there is none,
+// the identity which sorts first is returned, so that the result is stable.
+func (key *Key) primaryIdentity() *openpgp.Identity {
+	var first *openpgp.Identity
+	for _, id := range key.Identities {
+		if id.SelfSignature != nil && id.SelfSignature.IsPrimaryId != nil && *id.SelfSignature.IsPrimaryId {
+			return id
+		}
+		if first == nil || id.Name < first.Name {
+			first = id
 		}
 	}
+	return first
+}
 
-	r := Key{*key}
-	return &r, nil
+// Fingerprint returns the fingerprint of the primary key, in upper case hex,
+// as shown by gpg --fingerprint without the spaces.
+func (key *Key) Fingerprint() string {
+	return fingerprintString(key.PrimaryKey)
+}
+
+func fingerprintString(pk *packet.PublicKey) string {
+	return strings.ToUpper(hex.EncodeToString(pk.Fingerprint[:]))
 }
 
 // Armor returns the public part of a key in armored format.
 func (key *Key) Armor() (string, error) {
+	if key == nil {
+		return "", ErrNilKey
+	}
 	buf := new(bytes.Buffer)
 	armor, err := armor.Encode(buf, openpgp.PublicKeyType, nil)
 	if err != nil {
 		return "", err
 	}
-	key.Serialize(armor)
+	key.exportable().Serialize(armor)
 	armor.Close()
 
 	return buf.String(), nil
 }
 
-// ArmorPrivate returns the private part of a key in armored format.
+// ArmorPrivate returns the private part of a key in armored format. A Version
+// header is added if config.EmitVersionHeader is set.
 //
 // Note: if you want to protect the string against varous low-level attacks,
 // you should look at https://github.com/stouset/go.secrets and
 // https://github.com/worr/secstring and then re-implement this function.
 func (key *Key) ArmorPrivate(config *Config) (string, error) {
+	if key == nil {
+		return "", ErrNilKey
+	}
 	buf := new(bytes.Buffer)
-	armor, err := armor.Encode(buf, openpgp.PrivateKeyType, nil)
+	armor, err := config.armorEncode(buf, openpgp.PrivateKeyType, config.armorHeaders())
+	if err != nil {
+		return "", err
+	}
+	err = key.serializePrivate(armor)
 	if err != nil {
 		return "", err
 	}
-	c := config.Config
-	key.SerializePrivate(armor, &c)
 	armor.Close()
 
 	return buf.String(), nil
@@ -148,14 +670,27 @@ func (key *Key) ArmorPrivate(config *Config) (string, error) {
 // A keyring is simply one (or more) keys in binary format.
 func (key *Key) Keyring() []byte {
 	buf := new(bytes.Buffer)
-	key.Serialize(buf)
+	key.exportable().Serialize(buf)
 	return buf.Bytes()
 }
 
 // A secring is simply one (or more) keys in binary format.
-func (key *Key) Secring(config *Config) []byte {
+//
+// The key is written with its existing signatures, like ArmorPrivate does,
+// rather than re-signed, so that the subpackets the openpgp package can't
+// write, such as the preferred keyserver, are kept. config is therefore not
+// used. An error is returned if the key has no private part.
+func (key *Key) Secring(config *Config) ([]byte, error) {
+	if key == nil {
+		return nil, ErrNilKey
+	}
+	if key.PrivateKey == nil {
+		return nil, errors.New("gpgeez: key has no private material")
+	}
 	buf := new(bytes.Buffer)
-	c := config.Config
-	key.SerializePrivate(buf, &c)
-	return buf.Bytes()
+	err := key.serializePrivate(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
@@ -0,0 +1,60 @@
+// Package gpgeeztest provides helpers for tests which use gpgeez keys. The
+// helpers fail the test instead of returning errors.
+package gpgeeztest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alokmenghrajani/gpgeez"
+)
+
+// Config is the configuration used by the helpers. It creates ECDSA P-256
+// keys, valid for a year, which are much faster to generate than RSA keys.
+// The keys use crypto/rand, but are only meant for tests.
+func Config() *gpgeez.Config {
+	return &gpgeez.Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+}
+
+// MustCreateKey creates a key for name and email with Config.
+func MustCreateKey(t *testing.T, name, email string) *gpgeez.Key {
+	t.Helper()
+	key, err := gpgeez.CreateKey(name, "", email, Config())
+	if err != nil {
+		t.Fatalf("gpgeez.CreateKey: %v", err)
+	}
+	return key
+}
+
+// MustArmor returns the public part of key in armored format.
+func MustArmor(t *testing.T, key *gpgeez.Key) string {
+	t.Helper()
+	armored, err := key.Armor()
+	if err != nil {
+		t.Fatalf("Key.Armor: %v", err)
+	}
+	return armored
+}
+
+// MustSign returns a binary detached signature of data made by key.
+func MustSign(t *testing.T, key *gpgeez.Key, data []byte) []byte {
+	t.Helper()
+	sig, err := key.Sign(bytes.NewReader(data), Config())
+	if err != nil {
+		t.Fatalf("Key.Sign: %v", err)
+	}
+	return sig
+}
+
+// AssertFingerprint marks the test as failed if the fingerprint of key isn't
+// expected. Like Key.SubkeyByFingerprint, the comparison is case-insensitive
+// and ignores spaces.
+func AssertFingerprint(t *testing.T, key *gpgeez.Key, expected string) {
+	t.Helper()
+	normalized := strings.ToUpper(strings.Replace(expected, " ", "", -1))
+	if key.Fingerprint() != normalized {
+		t.Errorf("fingerprint is %s, expected %s", key.Fingerprint(), expected)
+	}
+}
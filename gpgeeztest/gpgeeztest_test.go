@@ -0,0 +1,20 @@
+package gpgeeztest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/alokmenghrajani/gpgeez"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHelpers(t *testing.T) {
+	key := MustCreateKey(t, "Joe", "joe@example.com")
+	imported, err := gpgeez.ImportPublicKey(MustArmor(t, key))
+	assert.Nil(t, err, "ImportPublicKey errored")
+	AssertFingerprint(t, imported, strings.ToLower(key.Fingerprint()))
+
+	sig := MustSign(t, key, []byte("hello"))
+	assert.Nil(t, imported.Verify(bytes.NewReader([]byte("hello")), sig, Config()))
+}
@@ -0,0 +1,61 @@
+package gpgeez
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseArmoredKey(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	publicKey, err := key.Armor()
+	assert.Nil(t, err, "key.Armor() errored")
+	parsed, private, err := ParseArmoredKey(publicKey)
+	assert.Nil(t, err, "ParseArmoredKey errored")
+	assert.False(t, private)
+	assert.Equal(t, key.PrimaryKey.Fingerprint, parsed.PrimaryKey.Fingerprint)
+
+	privateKey, err := key.ArmorPrivate(&config)
+	assert.Nil(t, err, "key.ArmorPrivate() errored")
+	parsed, private, err = ParseArmoredKey(privateKey)
+	assert.Nil(t, err, "ParseArmoredKey errored")
+	assert.True(t, private)
+	assert.Equal(t, key.PrimaryKey.Fingerprint, parsed.PrimaryKey.Fingerprint)
+
+	_, err = ImportPrivateKey(publicKey)
+	assert.NotNil(t, err, "ImportPrivateKey should reject a public key")
+}
+
+func TestParseKey(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	secring, err := key.Secring(nil)
+	assert.Nil(t, err, "Secring errored")
+	public, err := ImportPublicKey(mustArmor(t, key))
+	assert.Nil(t, err, "ImportPublicKey errored")
+	_, err = public.Secring(nil)
+	assert.NotNil(t, err, "public keys have no secring")
+	inputs := map[string]bool{
+		mustArmor(t, key):        false,
+		mustArmorPrivate(t, key): true,
+		string(key.Keyring()):    false,
+		string(secring):          true,
+	}
+	for input, private := range inputs {
+		parsed, hasPrivate, err := ParseKey([]byte(input))
+		assert.Nil(t, err, "ParseKey errored")
+		assert.Equal(t, private, hasPrivate)
+		assert.Equal(t, key.PrimaryKey.Fingerprint, parsed.PrimaryKey.Fingerprint)
+	}
+
+	ssh, err := key.MarshalSSHPrivateKey()
+	assert.Nil(t, err, "MarshalSSHPrivateKey errored")
+	_, _, err = ParseKey(ssh)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,70 @@
+package gpgeez
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The fixtures in testdata were created with:
+//
+//	gpg --quick-gen-key "Test <algo> <<algo>@example.com>" <algo> default never
+//	gpg --export <fingerprint> > gnupg-<version>-<algo>.gpg
+//	gpg --armor --export <fingerprint> > gnupg-<version>-<algo>.asc
+var gnupgFixtures = []struct {
+	name        string
+	fingerprint string
+	// supported is false for algorithms the vendored openpgp package can't
+	// parse.
+	supported bool
+}{
+	{"gnupg-2.2-rsa3072", "7520A2A53EA9DFDF1999D73278117DB2B3C89818", true},
+	{"gnupg-2.2-nistp256", "6BB3CF845CA6CCB3BF301AD7193792E6179CEA4E", true},
+	{"gnupg-2.2-ed25519", "7AF81BF18E44A5312521D1B0B0239E537AB7698F", false},
+}
+
+func TestImportGnuPGFixtures(t *testing.T) {
+	for _, fixture := range gnupgFixtures {
+		for _, ext := range []string{".asc", ".gpg"} {
+			data, err := ioutil.ReadFile(filepath.Join("testdata", fixture.name+ext))
+			assert.Nil(t, err)
+
+			key, private, err := ParseKey(data)
+			if !fixture.supported {
+				assert.NotNil(t, err, fixture.name+ext+" should not be supported")
+				continue
+			}
+			assert.Nil(t, err, fixture.name+ext)
+			assert.False(t, private)
+			assert.Equal(t, fixture.fingerprint, key.Fingerprint(), fixture.name+ext)
+		}
+	}
+}
+
+func TestGnuPGImport(t *testing.T) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg not found in PATH")
+	}
+	home, err := ioutil.TempDir("", "gpgeez")
+	assert.Nil(t, err)
+	defer os.RemoveAll(home)
+
+	config := Config{Expiry: 365 * 24 * time.Hour}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	cmd := exec.Command("gpg", "--homedir", home, "--batch", "--import")
+	cmd.Stdin = strings.NewReader(mustArmor(t, key))
+	out, err := cmd.CombinedOutput()
+	assert.Nil(t, err, string(out))
+
+	out, err = exec.Command("gpg", "--homedir", home, "--batch", "--with-colons", "--list-keys", key.Fingerprint()).CombinedOutput()
+	assert.Nil(t, err, string(out))
+	assert.True(t, strings.Contains(string(out), "fpr:::::::::"+key.Fingerprint()+":"))
+}
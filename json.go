@@ -0,0 +1,115 @@
+package gpgeez
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// jsonKey is the JSON representation of a Key.
+type jsonKey struct {
+	jsonPublicKey
+	UIDs             []string        `json:"uids"`
+	Subkeys          []jsonPublicKey `json:"subkeys"`
+	ArmoredPublicKey string          `json:"armored_public_key"`
+}
+
+type jsonPublicKey struct {
+	Fingerprint string     `json:"fingerprint"`
+	Algorithm   string     `json:"algorithm"`
+	KeySize     int        `json:"key_size"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+// MarshalJSON returns a description of the public part of key, including the
+// armored public key. Private key material is never included.
+func (key *Key) MarshalJSON() ([]byte, error) {
+	armored, err := key.Armor()
+	if err != nil {
+		return nil, err
+	}
+
+	var expiry *uint32
+	if id := key.primaryIdentity(); id != nil && id.SelfSignature != nil {
+		expiry = id.SelfSignature.KeyLifetimeSecs
+	}
+	r := jsonKey{
+		jsonPublicKey:    newJSONPublicKey(key.PrimaryKey, expiry),
+		UIDs:             []string{},
+		Subkeys:          []jsonPublicKey{},
+		ArmoredPublicKey: armored,
+	}
+	for name := range key.Identities {
+		r.UIDs = append(r.UIDs, name)
+	}
+	sort.Strings(r.UIDs)
+	for _, subkey := range key.Subkeys {
+		r.Subkeys = append(r.Subkeys, newJSONPublicKey(subkey.PublicKey, subkey.Sig.KeyLifetimeSecs))
+	}
+	return json.Marshal(r)
+}
+
+// UnmarshalJSON parses the output of MarshalJSON. Only the armored public key
+// is used, the other fields are informational.
+func (key *Key) UnmarshalJSON(data []byte) error {
+	var r jsonKey
+	err := json.Unmarshal(data, &r)
+	if err != nil {
+		return err
+	}
+	k, err := ImportPublicKey(r.ArmoredPublicKey)
+	if err != nil {
+		return err
+	}
+	*key = *k
+	return nil
+}
+
+func newJSONPublicKey(pk *packet.PublicKey, lifetimeSecs *uint32) jsonPublicKey {
+	r := jsonPublicKey{
+		Fingerprint: fingerprintString(pk),
+		Algorithm:   algorithmName(pk.PubKeyAlgo),
+		KeySize:     keyBits(pk),
+		CreatedAt:   pk.CreationTime.UTC(),
+	}
+	if lifetimeSecs != nil && *lifetimeSecs != 0 {
+		expiresAt := r.CreatedAt.Add(time.Duration(*lifetimeSecs) * time.Second)
+		r.ExpiresAt = &expiresAt
+	}
+	return r
+}
+
+func algorithmName(algo packet.PublicKeyAlgorithm) string {
+	switch algo {
+	case packet.PubKeyAlgoRSA, packet.PubKeyAlgoRSAEncryptOnly, packet.PubKeyAlgoRSASignOnly:
+		return "RSA"
+	case packet.PubKeyAlgoDSA:
+		return "DSA"
+	case packet.PubKeyAlgoElGamal:
+		return "ElGamal"
+	case packet.PubKeyAlgoECDSA:
+		return "ECDSA"
+	case packet.PubKeyAlgoECDH:
+		return "ECDH"
+	}
+	return "unknown"
+}
+
+// keyBits returns the size of the modulus, or of the curve, of pk.
+func keyBits(pk *packet.PublicKey) int {
+	if pk.PubKeyAlgo == packet.PubKeyAlgoECDSA {
+		if pub, ok := pk.PublicKey.(*ecdsa.PublicKey); ok {
+			return pub.Curve.Params().BitSize
+		}
+		return 0
+	}
+	bits, err := pk.BitLength()
+	if err != nil {
+		return 0
+	}
+	return int(bits)
+}
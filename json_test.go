@@ -0,0 +1,31 @@
+package gpgeez
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-384"}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	data, err := json.Marshal(key)
+	assert.Nil(t, err, "MarshalJSON errored")
+	var fields map[string]interface{}
+	assert.Nil(t, json.Unmarshal(data, &fields))
+	assert.Equal(t, "ECDSA", fields["algorithm"])
+	assert.Equal(t, float64(384), fields["key_size"])
+	assert.Equal(t, []interface{}{"Joe (test key) <joe@example.com>"}, fields["uids"])
+	assert.NotNil(t, fields["expires_at"])
+	assert.False(t, strings.Contains(string(data), "PRIVATE"))
+
+	var parsed Key
+	assert.Nil(t, json.Unmarshal(data, &parsed))
+	assert.Equal(t, key.PrimaryKey.Fingerprint, parsed.PrimaryKey.Fingerprint)
+	assert.Nil(t, parsed.PrivateKey)
+}
@@ -0,0 +1,213 @@
+package gpgeez
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+// ReadKeyRing reads one or more keys in binary format, as returned by Keyring
+// or Secring.
+func ReadKeyRing(r io.Reader) ([]*Key, error) {
+	el, err := openpgp.ReadKeyRing(r)
+	if err != nil {
+		return nil, err
+	}
+	return toKeys(el), nil
+}
+
+// ReadArmoredKeyRing reads one or more public or private keys in armored
+// format.
+func ReadArmoredKeyRing(r io.Reader) ([]*Key, error) {
+	el, err := openpgp.ReadArmoredKeyRing(r)
+	if err != nil {
+		return nil, err
+	}
+	return toKeys(el), nil
+}
+
+// WriteKeyRing writes the public part of keys to w in binary format, like
+// gpg --export. It is the inverse of ReadKeyRing, except that local
+// certifications (see Config.NonExportable) are left out.
+func WriteKeyRing(w io.Writer, keys []*Key) error {
+	for _, key := range keys {
+		err := key.exportable().Serialize(w)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteArmoredKeyRing writes the public part of keys to w as a single armored
+// block, like gpg --armor --export. It is the inverse of ReadArmoredKeyRing.
+func WriteArmoredKeyRing(w io.Writer, keys []*Key) error {
+	armor, err := armor.Encode(w, openpgp.PublicKeyType, nil)
+	if err != nil {
+		return err
+	}
+	err = WriteKeyRing(armor, keys)
+	if err != nil {
+		return err
+	}
+	return armor.Close()
+}
+
+func toKeys(el openpgp.EntityList) []*Key {
+	keys := make([]*Key, len(el))
+	for i, e := range el {
+		keys[i] = &Key{*e}
+	}
+	return keys
+}
+
+// KeyRing is a set of keys, used to compute web-of-trust paths and validity.
+//
+// A KeyRing is safe for concurrent use: Add and Remove can be called while
+// other goroutines look keys up, verify signatures or compute validity.
+// MarginalsNeeded must however be set before the KeyRing is shared, and the
+// keys themselves must not be modified while they are in use.
+type KeyRing struct {
+	// MarginalsNeeded is the number of marginally trusted keys which must
+	// certify a key for it to be fully valid, see ComputeValidity. The
+	// default, like gpg's --marginals-needed, is 3.
+	MarginalsNeeded int
+
+	mu   sync.RWMutex
+	keys []*Key
+}
+
+// NewKeyRing returns a KeyRing containing keys.
+func NewKeyRing(keys ...*Key) *KeyRing {
+	return &KeyRing{keys: keys}
+}
+
+// Keys returns a copy of the list of keys in kr.
+func (kr *KeyRing) Keys() []*Key {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	return append([]*Key(nil), kr.keys...)
+}
+
+// Len returns the number of keys in kr.
+func (kr *KeyRing) Len() int {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	return len(kr.keys)
+}
+
+// Add adds keys to kr.
+func (kr *KeyRing) Add(keys ...*Key) {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	kr.keys = append(kr.keys, keys...)
+}
+
+// Remove removes the keys with the given fingerprint from kr, and returns
+// whether there were any. The fingerprint is normalized like in
+// SubkeyByFingerprint.
+func (kr *KeyRing) Remove(fingerprint string) bool {
+	fingerprint = normalizeFingerprint(fingerprint)
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	var keys []*Key
+	for _, key := range kr.keys {
+		if key.Fingerprint() != fingerprint {
+			keys = append(keys, key)
+		}
+	}
+	removed := len(keys) != len(kr.keys)
+	kr.keys = keys
+	return removed
+}
+
+// FindByFingerprint returns the key with the given primary key fingerprint,
+// or nil. The fingerprint is normalized like in SubkeyByFingerprint.
+func (kr *KeyRing) FindByFingerprint(fingerprint string) *Key {
+	fingerprint = normalizeFingerprint(fingerprint)
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	for _, key := range kr.keys {
+		if key.Fingerprint() == fingerprint {
+			return key
+		}
+	}
+	return nil
+}
+
+// FindByKeyId returns the key whose primary key or one of its subkeys has the
+// given key id, or nil.
+func (kr *KeyRing) FindByKeyId(keyId uint64) *Key {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	for _, key := range kr.keys {
+		if len(openpgp.EntityList{&key.Entity}.KeysById(keyId)) > 0 {
+			return key
+		}
+	}
+	return nil
+}
+
+// FindByEmail returns the keys which have a user id with the given email
+// address. The comparison is case-insensitive.
+func (kr *KeyRing) FindByEmail(email string) []*Key {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	var r []*Key
+	for _, key := range kr.keys {
+		for _, id := range key.Identities {
+			if id.UserId != nil && strings.EqualFold(id.UserId.Email, email) {
+				r = append(r, key)
+				break
+			}
+		}
+	}
+	return r
+}
+
+// Export writes the public part of the keys in kr to w, see
+// WriteArmoredKeyRing.
+func (kr *KeyRing) Export(w io.Writer) error {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	return WriteArmoredKeyRing(w, kr.keys)
+}
+
+// Filter returns a new KeyRing, with the same MarginalsNeeded, containing the
+// keys of kr for which pred returns true.
+func (kr *KeyRing) Filter(pred func(*Key) bool) *KeyRing {
+	r := &KeyRing{MarginalsNeeded: kr.MarginalsNeeded}
+	for _, key := range kr.Keys() {
+		if pred(key) {
+			r.keys = append(r.keys, key)
+		}
+	}
+	return r
+}
+
+// Verify checks that sig is a valid binary detached signature of the data
+// read from r, made by one of the keys in kr, and returns that key. Like
+// VerifySignedAfter, it applies the checks of Key.Verify with the default
+// Config.
+func (kr *KeyRing) Verify(r io.Reader, sig []byte) (*Key, error) {
+	s, err := parseSignature(sig)
+	if err != nil {
+		return nil, err
+	}
+	if s.IssuerKeyId == nil {
+		return nil, errors.New("gpgeez: signature has no issuer")
+	}
+	key := kr.FindByKeyId(*s.IssuerKeyId)
+	if key == nil {
+		return nil, errors.New("gpgeez: signature was not made by a key in the keyring")
+	}
+	err = key.Verify(r, sig, &Config{})
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,140 @@
+package gpgeez
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadKeyRing(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	keys, err := ReadKeyRing(bytes.NewReader(key.Keyring()))
+	assert.Nil(t, err, "ReadKeyRing errored")
+	assert.Equal(t, 1, len(keys))
+	assert.Equal(t, key.PrimaryKey.Fingerprint, keys[0].PrimaryKey.Fingerprint)
+
+	keys, err = ReadArmoredKeyRing(strings.NewReader(mustArmorPrivate(t, key)))
+	assert.Nil(t, err, "ReadArmoredKeyRing errored")
+	assert.Equal(t, 1, len(keys))
+	assert.NotNil(t, keys[0].PrivateKey)
+}
+
+func TestWriteArmoredKeyRing(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour}
+	var keys []*Key
+	for _, name := range []string{"Joe", "Jane"} {
+		key, err := CreateKey(name, "test key", name+"@example.com", &config)
+		assert.Nil(t, err, "CreateKey errored")
+		keys = append(keys, key)
+	}
+
+	buf := new(bytes.Buffer)
+	assert.Nil(t, WriteArmoredKeyRing(buf, keys))
+	read, err := ReadArmoredKeyRing(buf)
+	assert.Nil(t, err, "ReadArmoredKeyRing errored")
+	assert.Equal(t, len(keys), len(read))
+	for i, key := range keys {
+		assert.Equal(t, key.Keyring(), read[i].Keyring())
+	}
+}
+
+func TestKeyRingVerify(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	var keys []*Key
+	for _, name := range []string{"Joe", "Jane", "Jim"} {
+		key, err := CreateKey(name, "test key", name+"@example.com", &config)
+		assert.Nil(t, err, "CreateKey errored")
+		keys = append(keys, key)
+	}
+	kr := NewKeyRing(keys[:2]...)
+
+	sig, err := keys[1].Sign(strings.NewReader("hello"), &config)
+	assert.Nil(t, err, "Sign errored")
+	signer, err := kr.Verify(strings.NewReader("hello"), sig)
+	assert.Nil(t, err, "Verify errored")
+	assert.Equal(t, keys[1], signer)
+
+	_, err = kr.Verify(strings.NewReader("goodbye"), sig)
+	assert.NotNil(t, err, "the data was modified")
+
+	sig, err = keys[2].Sign(strings.NewReader("hello"), &config)
+	assert.Nil(t, err, "Sign errored")
+	_, err = kr.Verify(strings.NewReader("hello"), sig)
+	assert.NotNil(t, err, "the signer is not in the keyring")
+}
+
+func TestKeyRingFilter(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	public, err := ImportPublicKey(mustArmor(t, key))
+	assert.Nil(t, err, "ImportPublicKey errored")
+
+	kr := NewKeyRing(key, public)
+	kr.MarginalsNeeded = 2
+	private := kr.Filter(func(k *Key) bool { return k.PrivateKey != nil })
+	assert.Equal(t, []*Key{key}, private.Keys())
+	assert.Equal(t, 2, private.MarginalsNeeded)
+	assert.Equal(t, 2, len(kr.Keys()), "kr is unchanged")
+	assert.Equal(t, 0, len(kr.Filter(func(*Key) bool { return false }).Keys()))
+}
+
+func TestKeyRingConcurrency(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	var keys []*Key
+	for _, name := range []string{"Joe", "Jane", "Jim", "Jill"} {
+		key, err := CreateKey(name, "test key", name+"@example.com", &config)
+		assert.Nil(t, err, "CreateKey errored")
+		keys = append(keys, key)
+	}
+	sig, err := keys[0].Sign(strings.NewReader("hello"), &config)
+	assert.Nil(t, err, "Sign errored")
+
+	// Run with go test -race to check for data races.
+	kr := NewKeyRing(keys[0])
+	var wg sync.WaitGroup
+	for _, key := range keys[1:] {
+		wg.Add(2)
+		go func(key *Key) {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				kr.Add(key)
+				kr.Remove(key.Fingerprint())
+			}
+			kr.Add(key)
+		}(key)
+		go func(key *Key) {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				kr.FindByFingerprint(key.Fingerprint())
+				kr.FindByKeyId(key.PrimaryKey.KeyId)
+				kr.FindByEmail(key.primaryIdentity().UserId.Email)
+				kr.Len()
+				assert.Nil(t, kr.Export(ioutil.Discard))
+				signer, err := kr.Verify(strings.NewReader("hello"), sig)
+				assert.Nil(t, err, "Verify errored")
+				assert.Equal(t, keys[0], signer)
+				kr.ComputeValidity(key, keys[:1])
+			}
+		}(key)
+	}
+	wg.Wait()
+
+	assert.Equal(t, len(keys), kr.Len())
+	for _, key := range keys {
+		assert.Equal(t, key, kr.FindByFingerprint(key.Fingerprint()))
+		assert.Equal(t, key, kr.FindByKeyId(key.Subkeys[0].PublicKey.KeyId))
+		assert.Equal(t, []*Key{key}, kr.FindByEmail(strings.ToUpper(key.primaryIdentity().UserId.Email)))
+	}
+	assert.True(t, kr.Remove(keys[1].Fingerprint()))
+	assert.False(t, kr.Remove(keys[1].Fingerprint()))
+	assert.Nil(t, kr.FindByFingerprint(keys[1].Fingerprint()))
+}
@@ -0,0 +1,116 @@
+package gpgeez
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// packetTagLiteralData is the tag of literal data packets, see
+// https://tools.ietf.org/html/rfc4880#section-5.9
+const packetTagLiteralData = 11
+
+// SignMessage returns a signed message containing the data read from r, in
+// binary format, like gpg --sign: a one-pass signature packet, a literal data
+// packet and the signature. Unlike with Sign, the data travels with its
+// signature and can be verified in a single pass, e.g. with
+// openpgp.ReadMessage.
+//
+// In text mode, see Config.LiteralDataFormat, line endings are converted to
+// CRLF and a text signature is made.
+func (key *Key) SignMessage(r io.Reader, config *Config) ([]byte, error) {
+	priv, err := key.signingKey(config.now())
+	if err != nil {
+		return nil, err
+	}
+	format := config.literalDataFormat()
+	data, err := readLiteralData(r, format)
+	if err != nil {
+		return nil, err
+	}
+	sigType := packet.SignatureType(packet.SigTypeBinary)
+	if format != literalBinary {
+		sigType = packet.SigTypeText
+	}
+	h := config.hashFor(HashForDataSignature)
+	sig, err := detachSign(priv, sigType, h, bytes.NewReader(data), config)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	ops := &packet.OnePassSignature{
+		SigType:    sigType,
+		Hash:       h,
+		PubKeyAlgo: priv.PubKeyAlgo,
+		KeyId:      priv.KeyId,
+		IsLast:     true,
+	}
+	err = ops.Serialize(buf)
+	if err != nil {
+		return nil, err
+	}
+	serializeLiteral(buf, format, data, config.now())
+	buf.Write(sig)
+	return buf.Bytes(), nil
+}
+
+// Formats of literal data packets, see Config.LiteralDataFormat.
+const (
+	literalBinary = 'b'
+	literalText   = 't'
+	literalUTF8   = 'u'
+)
+
+// literalDataFormat returns config.LiteralDataFormat, or binary if it isn't
+// set.
+func (config *Config) literalDataFormat() byte {
+	if config.LiteralDataFormat == 0 {
+		return literalBinary
+	}
+	return config.LiteralDataFormat
+}
+
+// readLiteralData reads the data of a literal data packet of the given format
+// from r. Text is converted to canonical CRLF line endings, and UTF-8 text
+// must be valid.
+func readLiteralData(r io.Reader, format byte) ([]byte, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil || format == literalBinary {
+		return data, err
+	}
+	if format == literalUTF8 && !utf8.Valid(data) {
+		return nil, errors.New("gpgeez: literal data is not valid UTF-8")
+	}
+	return canonicalText(data), nil
+}
+
+// canonicalText converts the lone LFs of data to CRLF, the way
+// openpgp.NewCanonicalTextHash does, see
+// https://tools.ietf.org/html/rfc4880#section-5.2.1
+func canonicalText(data []byte) []byte {
+	r := make([]byte, 0, len(data))
+	for i, c := range data {
+		if c == '\n' && (i == 0 || data[i-1] != '\r') {
+			r = append(r, '\r')
+		}
+		r = append(r, c)
+	}
+	return r
+}
+
+// serializeLiteral writes a literal data packet with no file name, see
+// https://tools.ietf.org/html/rfc4880#section-5.9
+func serializeLiteral(w *bytes.Buffer, format byte, data []byte, t time.Time) {
+	w.WriteByte(0xc0 | packetTagLiteralData)
+	writeLength(w, 6+len(data))
+	w.Write([]byte{format, 0})
+	binary.Write(w, binary.BigEndian, uint32(t.Unix()))
+	w.Write(data)
+}
@@ -0,0 +1,89 @@
+package gpgeez
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+func TestSignMessage(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	data := strings.Repeat("hello world\n", 1000)
+
+	message, err := key.SignMessage(strings.NewReader(data), &config)
+	assert.Nil(t, err, "SignMessage errored")
+	md, err := openpgp.ReadMessage(bytes.NewReader(message), openpgp.EntityList{&key.Entity}, nil, nil)
+	assert.Nil(t, err, "ReadMessage errored")
+	assert.True(t, md.IsSigned)
+	assert.Equal(t, key.PrimaryKey.KeyId, md.SignedByKeyId)
+	body, err := ioutil.ReadAll(md.UnverifiedBody)
+	assert.Nil(t, err)
+	assert.Equal(t, data, string(body))
+	assert.Nil(t, md.SignatureError)
+	assert.True(t, md.LiteralData.IsBinary)
+
+	// Tampering with the data is detected.
+	message[len(message)/2] ^= 1
+	md, err = openpgp.ReadMessage(bytes.NewReader(message), openpgp.EntityList{&key.Entity}, nil, nil)
+	assert.Nil(t, err, "ReadMessage errored")
+	ioutil.ReadAll(md.UnverifiedBody)
+	assert.NotNil(t, md.SignatureError)
+}
+
+func TestLiteralDataFormat(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256", LiteralDataFormat: 't'}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	data := "hello\nworld\r\n"
+
+	message, err := key.SignMessage(strings.NewReader(data), &config)
+	assert.Nil(t, err, "SignMessage errored")
+	md, err := openpgp.ReadMessage(bytes.NewReader(message), openpgp.EntityList{&key.Entity}, nil, nil)
+	assert.Nil(t, err, "ReadMessage errored")
+	body, err := ioutil.ReadAll(md.UnverifiedBody)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello\r\nworld\r\n", string(body))
+	assert.Nil(t, md.SignatureError)
+	assert.False(t, md.LiteralData.IsBinary)
+	assert.Equal(t, packet.SignatureType(packet.SigTypeText), md.Signature.SigType)
+
+	ciphertext, err := Encrypt(strings.NewReader(data), []*Key{key}, key, &config)
+	assert.Nil(t, err, "Encrypt errored")
+	md, err = openpgp.ReadMessage(bytes.NewReader(ciphertext), openpgp.EntityList{&key.Entity}, nil, nil)
+	assert.Nil(t, err, "ReadMessage errored")
+	body, err = ioutil.ReadAll(md.UnverifiedBody)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello\r\nworld\r\n", string(body))
+	assert.False(t, md.LiteralData.IsBinary)
+	// The embedded signature is binary, over the converted data.
+	assert.Nil(t, md.SignatureError)
+	assert.Equal(t, packet.SignatureType(packet.SigTypeBinary), md.Signature.SigType)
+
+	config.LiteralDataFormat = 'u'
+	message, err = key.SignMessage(strings.NewReader("héllo\n"), &config)
+	assert.Nil(t, err, "SignMessage errored")
+	assert.Equal(t, byte('u'), message[bytes.Index(message, []byte("héllo"))-6])
+	_, err = key.SignMessage(strings.NewReader("\xff"), &config)
+	assert.NotNil(t, err, "invalid UTF-8 must be rejected")
+
+	// Encrypt can't write UTF-8 literal data, and falls back to text.
+	logs := new(bytes.Buffer)
+	config.Logger = slog.New(slog.NewTextHandler(logs, nil))
+	_, err = key.EncryptToSelf(strings.NewReader("héllo\n"), &config)
+	assert.Nil(t, err, "EncryptToSelf errored")
+	assert.Contains(t, logs.String(), "level=WARN msg=\"UTF-8 literal data is not supported, using text\"")
+	config.Logger = nil
+
+	config.LiteralDataFormat = 'x'
+	_, err = CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.NotNil(t, err, "unknown formats must be rejected")
+}
@@ -0,0 +1,54 @@
+package gpgeez
+
+import (
+	"encoding/binary"
+
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// notationHumanReadable is the flag of notations whose value is text, see
+// https://tools.ietf.org/html/rfc4880#section-5.2.3.16
+const notationHumanReadable = 0x80
+
+// NotationsFromSignature returns the human-readable notations in the hashed
+// area of sig, keyed by name. Notations in the unhashed area are ignored,
+// since anyone can change them.
+func NotationsFromSignature(sig *packet.Signature) map[string]string {
+	r := make(map[string]string)
+	subpackets, err := hashedSubpackets(sig)
+	if err != nil {
+		return r
+	}
+	for _, sp := range subpackets {
+		if sp.subpacketType != subpacketNotationData || len(sp.contents) < 8 {
+			continue
+		}
+		nameLength := int(binary.BigEndian.Uint16(sp.contents[4:]))
+		valueLength := int(binary.BigEndian.Uint16(sp.contents[6:]))
+		if sp.contents[0]&notationHumanReadable == 0 || len(sp.contents) != 8+nameLength+valueLength {
+			continue
+		}
+		name := string(sp.contents[8 : 8+nameLength])
+		r[name] = string(sp.contents[8+nameLength:])
+	}
+	return r
+}
+
+// SelfSignatureNotations returns the human-readable notations of the primary
+// identity's self-signature.
+func (key *Key) SelfSignatureNotations() map[string]string {
+	id := key.primaryIdentity()
+	if id == nil || id.SelfSignature == nil {
+		return make(map[string]string)
+	}
+	return NotationsFromSignature(id.SelfSignature)
+}
+
+// notationSubpacket returns a human-readable notation subpacket.
+func notationSubpacket(name, value string, critical bool) subpacket {
+	contents := []byte{notationHumanReadable, 0, 0, 0}
+	contents = append(contents, byte(len(name)>>8), byte(len(name)), byte(len(value)>>8), byte(len(value)))
+	contents = append(contents, name...)
+	contents = append(contents, value...)
+	return subpacket{subpacketNotationData, critical, contents}
+}
@@ -0,0 +1,27 @@
+package gpgeez
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelfSignatureNotations(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	assert.Equal(t, map[string]string{}, key.SelfSignatureNotations())
+
+	id := key.primaryIdentity()
+	h, err := hashUserId(id.UserId.Id, key.PrimaryKey, id.SelfSignature)
+	assert.Nil(t, err)
+	extra := []subpacket{notationSubpacket("test@example.com", "hello", false)}
+	id.SelfSignature, err = signWithSubpackets(id.SelfSignature, h, key.PrivateKey, extra, &config)
+	assert.Nil(t, err, "signWithSubpackets errored")
+
+	// The notation survives a round trip.
+	key, err = ImportPublicKey(mustArmor(t, key))
+	assert.Nil(t, err, "ImportPublicKey errored")
+	assert.Equal(t, map[string]string{"test@example.com": "hello"}, key.SelfSignatureNotations())
+}
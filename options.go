@@ -0,0 +1,86 @@
+package gpgeez
+
+import (
+	"crypto"
+	"io"
+	"time"
+
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// Option configures a Config created by NewConfig.
+type Option func(*Config)
+
+// DefaultConfig returns the settings used by NewConfig when no options are
+// given: 3072-bit RSA keys (GnuPG's default) which expire after two years,
+// SHA-256 signatures and AES-256 encryption.
+func DefaultConfig() *Config {
+	return &Config{
+		Expiry:        2 * 365 * 24 * time.Hour,
+		RSABits:       3072,
+		DefaultHash:   crypto.SHA256,
+		DefaultCipher: packet.CipherAES256,
+	}
+}
+
+// NewConfig returns DefaultConfig, modified by opts.
+func NewConfig(opts ...Option) *Config {
+	config := DefaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+	return config
+}
+
+// WithExpiry sets Config.Expiry.
+func WithExpiry(d time.Duration) Option {
+	return func(c *Config) { c.Expiry = d }
+}
+
+// WithRSABits sets Config.RSABits.
+func WithRSABits(bits int) Option {
+	return func(c *Config) { c.RSABits = bits }
+}
+
+// WithKeyType sets Config.KeyType.
+func WithKeyType(keyType string) Option {
+	return func(c *Config) { c.KeyType = keyType }
+}
+
+// WithSubkeyType sets Config.SubkeyType.
+func WithSubkeyType(subkeyType string) Option {
+	return func(c *Config) { c.SubkeyType = subkeyType }
+}
+
+// WithCurve sets Config.Curve.
+func WithCurve(curve string) Option {
+	return func(c *Config) { c.Curve = curve }
+}
+
+// WithHash sets Config.DefaultHash.
+func WithHash(h crypto.Hash) Option {
+	return func(c *Config) { c.DefaultHash = h }
+}
+
+// WithCipher sets Config.DefaultCipher.
+func WithCipher(cipher packet.CipherFunction) Option {
+	return func(c *Config) { c.DefaultCipher = cipher }
+}
+
+// WithRand sets Config.Rand. See the warning there.
+func WithRand(r io.Reader) Option {
+	return func(c *Config) { c.Rand = r }
+}
+
+// WithPreferredKeyserver sets Config.PreferredKeyserver.
+func WithPreferredKeyserver(url string) Option {
+	return func(c *Config) { c.PreferredKeyserver = url }
+}
+
+// WithMaxKeyLifetime sets Config.MaxKeyLifetime and Config.ClampExpiry.
+func WithMaxKeyLifetime(max time.Duration, clamp bool) Option {
+	return func(c *Config) {
+		c.MaxKeyLifetime = max
+		c.ClampExpiry = clamp
+	}
+}
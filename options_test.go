@@ -0,0 +1,22 @@
+package gpgeez
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewConfig(t *testing.T) {
+	assert.Equal(t, DefaultConfig(), NewConfig())
+
+	config := NewConfig(WithExpiry(time.Hour), WithCurve("P-256"))
+	assert.Equal(t, time.Hour, config.Expiry)
+	assert.Equal(t, "P-256", config.Curve)
+	assert.Equal(t, 3072, config.RSABits)
+
+	key, err := CreateKey("Joe", "test key", "joe@example.com", config)
+	assert.Nil(t, err, "CreateKey errored")
+	assert.Equal(t, 256, keyBits(key.PrimaryKey))
+	assert.Equal(t, 3072, keyBits(key.Subkeys[0].PublicKey))
+}
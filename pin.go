@@ -0,0 +1,82 @@
+package gpgeez
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// PinStore maps email addresses to the fingerprint of the key expected for
+// them, for trust on first use (TOFU) style key pinning. The zero value is an
+// empty store, and a PinStore is safe for concurrent use.
+type PinStore struct {
+	mu   sync.RWMutex
+	pins map[string]string
+}
+
+// Pin records fingerprint as the expected key for email, replacing any
+// previous pin. Spaces in fingerprint are ignored, and emails are compared
+// case-insensitively.
+func (p *PinStore) Pin(email, fingerprint string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pins == nil {
+		p.pins = make(map[string]string)
+	}
+	p.pins[strings.ToLower(email)] = normalizeFingerprint(fingerprint)
+}
+
+// Verify returns an error if the email of key's primary user id is pinned to
+// a different key. Keys whose email isn't pinned are accepted; call Pin to
+// trust them on first use.
+func (p *PinStore) Verify(key *Key) error {
+	if key == nil {
+		return ErrNilKey
+	}
+	id := key.primaryIdentity()
+	if id == nil {
+		return errors.New("gpgeez: key has no identity")
+	}
+	p.mu.RLock()
+	pinned, ok := p.pins[strings.ToLower(id.UserId.Email)]
+	p.mu.RUnlock()
+	if ok && pinned != key.Fingerprint() {
+		return fmt.Errorf("gpgeez: %s is pinned to key %s, got %s", id.UserId.Email, pinned, key.Fingerprint())
+	}
+	return nil
+}
+
+// MarshalJSON returns the pins as a JSON object mapping emails to
+// fingerprints.
+func (p *PinStore) MarshalJSON() ([]byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.pins == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(p.pins)
+}
+
+// UnmarshalJSON replaces the pins with the ones from the output of
+// MarshalJSON.
+func (p *PinStore) UnmarshalJSON(data []byte) error {
+	var pins map[string]string
+	err := json.Unmarshal(data, &pins)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pins = make(map[string]string, len(pins))
+	for email, fingerprint := range pins {
+		p.pins[strings.ToLower(email)] = normalizeFingerprint(fingerprint)
+	}
+	return nil
+}
+
+// normalizeFingerprint returns fingerprint in the format of Key.Fingerprint.
+func normalizeFingerprint(fingerprint string) string {
+	return strings.ToUpper(strings.Replace(fingerprint, " ", "", -1))
+}
@@ -0,0 +1,32 @@
+package gpgeez
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPinStore(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	other, err := CreateKey("Joe", "other key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	var pins PinStore
+	assert.Nil(t, pins.Verify(key), "unpinned keys must be accepted")
+	assert.Nil(t, pins.Verify(other), "unpinned keys must be accepted")
+
+	pins.Pin("Joe@Example.com", key.Fingerprint())
+	assert.Nil(t, pins.Verify(key))
+	assert.NotNil(t, pins.Verify(other))
+
+	data, err := json.Marshal(&pins)
+	assert.Nil(t, err, "MarshalJSON errored")
+	var restored PinStore
+	assert.Nil(t, json.Unmarshal(data, &restored), "UnmarshalJSON errored")
+	assert.Nil(t, restored.Verify(key))
+	assert.NotNil(t, restored.Verify(other))
+}
@@ -0,0 +1,95 @@
+package gpgeez
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// KeyPolicy describes the requirements of an organization for its keys. The
+// zero value of each field disables the corresponding check.
+type KeyPolicy struct {
+	// MinKeyStrength is the minimum strength of the primary key and of the
+	// active subkeys, as computed by KeyStrength.
+	MinKeyStrength int
+	// MaxExpiry is the maximum lifetime of the primary key and of the active
+	// subkeys. Keys which don't expire violate the policy.
+	MaxExpiry time.Duration
+	// AllowedAlgorithms lists the allowed public key algorithms, by the names
+	// used in MarshalJSON: "RSA", "DSA", "ElGamal", "ECDSA" or "ECDH".
+	AllowedAlgorithms []string
+	// RequireSubkeys requires the key to have at least one active subkey.
+	RequireSubkeys bool
+	// RequireCrossCert requires signing subkeys to be cross-certified, i.e. to
+	// have an embedded primary key binding signature.
+	RequireCrossCert bool
+}
+
+// PolicyViolation describes why a key doesn't comply with a KeyPolicy. Field
+// is the name of the KeyPolicy field which was violated.
+type PolicyViolation struct {
+	Field   string
+	Message string
+}
+
+func (v PolicyViolation) String() string {
+	return v.Field + ": " + v.Message
+}
+
+// Check returns the ways in which key violates p, or nil if it complies.
+func (p *KeyPolicy) Check(key *Key) []PolicyViolation {
+	var r []PolicyViolation
+	violation := func(field, format string, args ...interface{}) {
+		r = append(r, PolicyViolation{field, fmt.Sprintf(format, args...)})
+	}
+
+	var lifetime *uint32
+	if id := key.primaryIdentity(); id != nil && id.SelfSignature != nil {
+		lifetime = id.SelfSignature.KeyLifetimeSecs
+	}
+	p.checkPublicKey(key.PrimaryKey, lifetime, "primary key", violation)
+
+	now := time.Now()
+	active := 0
+	for _, subkey := range key.Subkeys {
+		if !isActiveSubkey(subkey, now) {
+			continue
+		}
+		active++
+		name := "subkey " + subkey.PublicKey.KeyIdString()
+		p.checkPublicKey(subkey.PublicKey, subkey.Sig.KeyLifetimeSecs, name, violation)
+		if p.RequireCrossCert && subkey.Sig.FlagsValid && subkey.Sig.FlagSign && subkey.Sig.EmbeddedSignature == nil {
+			violation("RequireCrossCert", "signing %s is not cross-certified", name)
+		}
+	}
+	if p.RequireSubkeys && active == 0 {
+		violation("RequireSubkeys", "key has no active subkeys")
+	}
+	return r
+}
+
+func (p *KeyPolicy) checkPublicKey(pk *packet.PublicKey, lifetime *uint32, name string, violation func(field, format string, args ...interface{})) {
+	if p.MinKeyStrength > 0 {
+		if strength := keyStrength(pk); strength < p.MinKeyStrength {
+			violation("MinKeyStrength", "%s has a strength of %d bits, need %d", name, strength, p.MinKeyStrength)
+		}
+	}
+	if p.MaxExpiry > 0 {
+		if lifetime == nil || *lifetime == 0 {
+			violation("MaxExpiry", "%s doesn't expire", name)
+		} else if d := time.Duration(*lifetime) * time.Second; d > p.MaxExpiry {
+			violation("MaxExpiry", "%s has a lifetime of %v, which exceeds %v", name, d, p.MaxExpiry)
+		}
+	}
+	if len(p.AllowedAlgorithms) > 0 {
+		algorithm := algorithmName(pk.PubKeyAlgo)
+		allowed := false
+		for _, a := range p.AllowedAlgorithms {
+			allowed = allowed || a == algorithm
+		}
+		if !allowed {
+			violation("AllowedAlgorithms", "%s uses %s, which is not allowed", name, algorithm)
+		}
+	}
+}
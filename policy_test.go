@@ -0,0 +1,46 @@
+package gpgeez
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyPolicy(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, RSABits: 2048}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	policy := KeyPolicy{
+		MinKeyStrength:    112,
+		MaxExpiry:         2 * 365 * 24 * time.Hour,
+		AllowedAlgorithms: []string{"RSA"},
+		RequireSubkeys:    true,
+		RequireCrossCert:  true,
+	}
+	assert.Nil(t, policy.Check(key))
+
+	policy = KeyPolicy{MinKeyStrength: 128}
+	violations := policy.Check(key)
+	assert.Equal(t, 2, len(violations), "primary key and subkey are too weak")
+	assert.Equal(t, "MinKeyStrength", violations[0].Field)
+
+	policy = KeyPolicy{MaxExpiry: 30 * 24 * time.Hour, AllowedAlgorithms: []string{"ECDSA", "ECDH"}}
+	violations = policy.Check(key)
+	assert.Equal(t, 4, len(violations))
+
+	signing := *key
+	signing.Subkeys = append(signing.Subkeys[:0:0], key.Subkeys...)
+	sig := *signing.Subkeys[0].Sig
+	sig.FlagSign = true
+	signing.Subkeys[0].Sig = &sig
+	policy = KeyPolicy{RequireCrossCert: true}
+	violations = policy.Check(&signing)
+	assert.Equal(t, 1, len(violations))
+	assert.Equal(t, "RequireCrossCert", violations[0].Field)
+
+	signing.Subkeys = nil
+	policy = KeyPolicy{RequireSubkeys: true}
+	assert.Equal(t, []PolicyViolation{{"RequireSubkeys", "key has no active subkeys"}}, policy.Check(&signing))
+}
@@ -0,0 +1,138 @@
+package gpgeez
+
+import (
+	"crypto/rand"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// keyPoolUID is the user id of the keys created by a KeyPool.
+var keyPoolUID = UID{Name: "gpgeez ephemeral key"}
+
+// keyPoolRetryDelay is how long a KeyPool waits before trying again when a
+// key can't be created.
+const keyPoolRetryDelay = time.Second
+
+// KeyPool hands out freshly generated keys, e.g. ephemeral signing keys in a
+// multi-tenant service, without making the caller wait for key generation.
+// The keys are generated in the background. A KeyPool is safe for concurrent
+// use.
+//
+// Each key is only handed out once. When it is released, a replacement is
+// generated in the background, so that at most size keys exist at a time.
+// Close stops the generation.
+type KeyPool struct {
+	config *Config
+	keys   chan *Key
+	done   chan struct{}
+	closed sync.Once
+	// serial is held while generating a key from a random source other than
+	// crypto/rand, which may not be safe for concurrent use.
+	serial sync.Mutex
+}
+
+// NewKeyPool returns a pool of size keys, created with config and the user
+// id "gpgeez ephemeral key". The keys are generated in background
+// goroutines; NewKeyPool only checks config and returns immediately. The keys
+// are generated one at a time if config has its own random source.
+func NewKeyPool(config *Config, size int) (*KeyPool, error) {
+	if size <= 0 {
+		return nil, errors.New("gpgeez: pool size must be positive")
+	}
+	err := config.validate()
+	if err != nil {
+		return nil, err
+	}
+	_, err = config.expiry()
+	if err != nil {
+		return nil, err
+	}
+	p := &KeyPool{config: copyConfig(config), keys: make(chan *Key, size), done: make(chan struct{})}
+	for i := 0; i < size; i++ {
+		go p.generate()
+	}
+	return p, nil
+}
+
+// copyConfig returns a copy of config which shares no maps or slices with
+// it, so that the caller can modify config afterwards. Rand, Now and Logger
+// are still shared.
+func copyConfig(config *Config) *Config {
+	c := *config
+	if config.HashAlgorithmFor != nil {
+		c.HashAlgorithmFor = make(map[string]HashAlgorithm, len(config.HashAlgorithmFor))
+		for op, h := range config.HashAlgorithmFor {
+			c.HashAlgorithmFor[op] = h
+		}
+	}
+	c.PreferredHash = append([]HashAlgorithm(nil), config.PreferredHash...)
+	if config.CompressionConfig != nil {
+		compression := *config.CompressionConfig
+		c.CompressionConfig = &compression
+	}
+	return &c
+}
+
+// Borrow returns a key and the function to call once the key is no longer
+// needed, which starts the generation of its replacement. If all the keys
+// are borrowed or still being generated, Borrow waits for one. Once the pool
+// is closed, Borrow returns a nil key.
+func (p *KeyPool) Borrow() (*Key, func()) {
+	select {
+	case <-p.done:
+		return nil, func() {}
+	default:
+	}
+	select {
+	case key := <-p.keys:
+		var once sync.Once
+		return key, func() {
+			once.Do(func() { go p.generate() })
+		}
+	case <-p.done:
+		return nil, func() {}
+	}
+}
+
+// Close stops the generation of keys, including the retries of failed
+// generations, and makes Borrow return nil. Keys which were borrowed can
+// still be used.
+func (p *KeyPool) Close() {
+	p.closed.Do(func() { close(p.done) })
+}
+
+// generate adds a new key to the pool. Failures, which are not expected once
+// the config is validated, are logged and retried until the pool is closed.
+func (p *KeyPool) generate() {
+	for {
+		select {
+		case <-p.done:
+			return
+		default:
+		}
+		key, err := p.createKey()
+		if err == nil {
+			select {
+			case p.keys <- key:
+			case <-p.done:
+			}
+			return
+		}
+		p.config.log(slog.LevelError, "key pool generation failed", "error", err)
+		select {
+		case <-time.After(keyPoolRetryDelay):
+		case <-p.done:
+			return
+		}
+	}
+}
+
+func (p *KeyPool) createKey() (*Key, error) {
+	if p.config.Random() != rand.Reader {
+		p.serial.Lock()
+		defer p.serial.Unlock()
+	}
+	return CreateKeyMultiUID([]UID{keyPoolUID}, p.config)
+}
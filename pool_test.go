@@ -0,0 +1,89 @@
+package gpgeez
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyPool(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	pool, err := NewKeyPool(&config, 2)
+	assert.Nil(t, err, "NewKeyPool errored")
+
+	seen := make(map[string]bool)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key, release := pool.Borrow()
+			defer release()
+			mu.Lock()
+			defer mu.Unlock()
+			assert.False(t, seen[key.Fingerprint()], "keys are only handed out once")
+			seen[key.Fingerprint()] = true
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 6, len(seen))
+
+	pool.Close()
+	pool.Close()
+	key, release := pool.Borrow()
+	assert.Nil(t, key, "closed pools hand out no keys")
+	release()
+
+	_, err = NewKeyPool(&config, 0)
+	assert.NotNil(t, err)
+	config.MaxKeyLifetime = time.Hour
+	_, err = NewKeyPool(&config, 1)
+	assert.NotNil(t, err, "invalid configs are rejected upfront")
+}
+
+// failingReader is a random source which always fails.
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("no more randomness")
+}
+
+func TestKeyPoolClose(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256", Rand: failingReader{}}
+	pool, err := NewKeyPool(&config, 2)
+	assert.Nil(t, err, "NewKeyPool errored")
+
+	borrowed := make(chan *Key)
+	go func() {
+		key, _ := pool.Borrow()
+		borrowed <- key
+	}()
+	pool.Close()
+	select {
+	case key := <-borrowed:
+		assert.Nil(t, key)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Borrow didn't return after Close")
+	}
+}
+
+func TestKeyPoolConfigCopy(t *testing.T) {
+	config := Config{
+		Expiry:           365 * 24 * time.Hour,
+		Curve:            "P-256",
+		HashAlgorithmFor: map[string]HashAlgorithm{HashForDataSignature: sha512},
+		PreferredHash:    []HashAlgorithm{sha512, sha256},
+	}
+	pool, err := NewKeyPool(&config, 1)
+	assert.Nil(t, err, "NewKeyPool errored")
+	defer pool.Close()
+
+	config.HashAlgorithmFor[HashForDataSignature] = sha1
+	config.PreferredHash[0] = sha1
+	assert.Equal(t, HashAlgorithm(sha512), pool.config.HashAlgorithmFor[HashForDataSignature])
+	assert.Equal(t, []HashAlgorithm{sha512, sha256}, pool.config.PreferredHash)
+}
@@ -0,0 +1,83 @@
+package gpgeez
+
+import (
+	"crypto"
+	"fmt"
+
+	"golang.org/x/crypto/openpgp/packet"
+	"golang.org/x/crypto/openpgp/s2k"
+)
+
+// HashAlgorithm is an OpenPGP hash algorithm id, see
+// https://tools.ietf.org/html/rfc4880#section-9.4
+type HashAlgorithm uint8
+
+// Hash returns the corresponding crypto.Hash, or 0 if it is unknown.
+func (h HashAlgorithm) Hash() crypto.Hash {
+	r, _ := s2k.HashIdToHash(byte(h))
+	return r
+}
+
+// String returns the name of h, as used by LoadConfig, e.g. "SHA256".
+func (h HashAlgorithm) String() string {
+	switch h {
+	case md5:
+		return "MD5"
+	case ripemd160:
+		return "RIPEMD160"
+	}
+	for name, hash := range hashNames {
+		if hash == h.Hash() {
+			return name
+		}
+	}
+	return fmt.Sprintf("HashAlgorithm(%d)", uint8(h))
+}
+
+// CipherAlgorithm is an OpenPGP symmetric cipher id, see
+// https://tools.ietf.org/html/rfc4880#section-9.2
+type CipherAlgorithm uint8
+
+// CipherFunction returns the corresponding packet.CipherFunction.
+func (c CipherAlgorithm) CipherFunction() packet.CipherFunction {
+	return packet.CipherFunction(c)
+}
+
+// String returns the name of c, as used by LoadConfig, e.g. "AES256".
+func (c CipherAlgorithm) String() string {
+	for name, cipher := range cipherNames {
+		if cipher == c.CipherFunction() {
+			return name
+		}
+	}
+	return fmt.Sprintf("CipherAlgorithm(%d)", uint8(c))
+}
+
+// PreferredHashAlgorithms returns the hash preferences of the key, from the
+// most recent self-signature of the primary identity, most preferred first.
+func (key *Key) PreferredHashAlgorithms() []HashAlgorithm {
+	sig := key.latestSelfSignature()
+	if sig == nil {
+		return nil
+	}
+	r := make([]HashAlgorithm, len(sig.PreferredHash))
+	for i, id := range sig.PreferredHash {
+		r[i] = HashAlgorithm(id)
+	}
+	return r
+}
+
+// PreferredCipherAlgorithms returns the symmetric cipher preferences of the
+// key, from the most recent self-signature of the primary identity, most
+// preferred first.
+func (key *Key) PreferredCipherAlgorithms() []CipherAlgorithm {
+	sig := key.latestSelfSignature()
+	if sig == nil {
+		return nil
+	}
+	r := make([]CipherAlgorithm, len(sig.PreferredSymmetric))
+	for i, id := range sig.PreferredSymmetric {
+		r[i] = CipherAlgorithm(id)
+	}
+	return r
+}
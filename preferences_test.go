@@ -0,0 +1,68 @@
+package gpgeez
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/hex"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+func TestPreferredAlgorithms(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	imported, err := ImportPublicKey(mustArmor(t, key))
+	assert.Nil(t, err, "ImportPublicKey errored")
+
+	hashes := imported.PreferredHashAlgorithms()
+	assert.Equal(t, []HashAlgorithm{sha256, sha512, sha384, sha224, sha1}, hashes)
+	assert.Equal(t, crypto.SHA256, hashes[0].Hash())
+	assert.Equal(t, "SHA256", hashes[0].String())
+	assert.Equal(t, "MD5", HashAlgorithm(md5).String())
+	assert.Equal(t, "HashAlgorithm(100)", HashAlgorithm(100).String())
+
+	ciphers := imported.PreferredCipherAlgorithms()
+	assert.Equal(t, 5, len(ciphers))
+	assert.Equal(t, packet.CipherAES256, ciphers[0].CipherFunction())
+	assert.Equal(t, "AES256", ciphers[0].String())
+	assert.Equal(t, "3DES", ciphers[4].String())
+}
+
+// prefHashSubpacket returns the serialized hash preferences subpacket of the
+// self-signature of key's primary identity.
+func prefHashSubpacket(t *testing.T, key *Key) []byte {
+	subpackets, err := hashedSubpackets(key.primaryIdentity().SelfSignature)
+	assert.Nil(t, err, "hashedSubpackets errored")
+	for _, sp := range subpackets {
+		if sp.subpacketType == subpacketPrefHash {
+			buf := new(bytes.Buffer)
+			serializeSubpacket(buf, sp)
+			return buf.Bytes()
+		}
+	}
+	t.Fatal("the self-signature has no hash preferences")
+	return nil
+}
+
+func TestPreferredHashSubpacket(t *testing.T) {
+	// testdata/gnupg-2.2-prefs.gpg was created by GnuPG 2.2.40 with
+	//
+	//	default-preference-list SHA256 SHA512 SHA384 SHA224 SHA1 AES256 AES192 AES CAST5 3DES ZLIB BZIP2 ZIP Uncompressed
+	//
+	// in gpg.conf, and exported like the other fixtures.
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "gnupg-2.2-prefs.gpg"))
+	assert.Nil(t, err)
+	gnupg, _, err := ParseKey(data)
+	assert.Nil(t, err, "ParseKey errored")
+
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	assert.Equal(t, hex.EncodeToString(prefHashSubpacket(t, gnupg)), hex.EncodeToString(prefHashSubpacket(t, key)))
+}
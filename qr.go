@@ -0,0 +1,423 @@
+package gpgeez
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+)
+
+// qrCode is a QR code symbol, see ISO/IEC 18004. Only what is needed to
+// encode fingerprints is implemented: the alphanumeric mode, error
+// correction level M, and versions 1 to 6 (up to 154 characters).
+type qrCode struct {
+	size int
+	// modules are indexed by row then column, true is dark.
+	modules [][]bool
+	// function marks the modules which are not part of the data area.
+	function [][]bool
+}
+
+const qrAlphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// qrVersions lists, for versions 1 to 6 at error correction level M, the
+// number of blocks and the number of data and error correction codewords in
+// each block.
+var qrVersions = []struct{ blocks, data, ec int }{
+	{1, 16, 10},
+	{1, 28, 16},
+	{1, 44, 26},
+	{2, 32, 18},
+	{2, 43, 24},
+	{4, 27, 16},
+}
+
+// qrEncode returns the smallest QR code containing text, which must only use
+// the characters of the alphanumeric mode.
+func qrEncode(text string) (*qrCode, error) {
+	for _, r := range text {
+		if !strings.ContainsRune(qrAlphanumeric, r) {
+			return nil, errors.New("gpgeez: unsupported character in QR code: " + string(r))
+		}
+	}
+	version := 0
+	for i, v := range qrVersions {
+		if 4+9+11*(len(text)/2)+6*(len(text)%2) <= v.blocks*v.data*8 {
+			version = i + 1
+			break
+		}
+	}
+	if version == 0 {
+		return nil, errors.New("gpgeez: text too long for a QR code")
+	}
+	spec := qrVersions[version-1]
+	capacity := spec.blocks * spec.data
+
+	var bits qrBits
+	bits.write(2, 4) // alphanumeric mode
+	bits.write(uint(len(text)), 9)
+	for i := 0; i+1 < len(text); i += 2 {
+		bits.write(uint(45*strings.IndexByte(qrAlphanumeric, text[i])+strings.IndexByte(qrAlphanumeric, text[i+1])), 11)
+	}
+	if len(text)%2 == 1 {
+		bits.write(uint(strings.IndexByte(qrAlphanumeric, text[len(text)-1])), 6)
+	}
+	terminator := capacity*8 - bits.n
+	if terminator > 4 {
+		terminator = 4
+	}
+	bits.write(0, terminator)
+	bits.write(0, (8-bits.n%8)%8)
+	for pad := uint(0xec); len(bits.data) < capacity; pad ^= 0xec ^ 0x11 {
+		bits.write(pad, 8)
+	}
+
+	size := 17 + 4*version
+	q := &qrCode{size: size, modules: qrGrid(size), function: qrGrid(size)}
+	q.drawFunctionPatterns(version)
+	q.drawCodewords(qrCodewords(bits.data, spec.blocks, spec.ec))
+
+	// Pick the mask with the lowest penalty. Masks are XORed, so applying
+	// one twice removes it.
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormat(mask)
+		if p := q.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		q.applyMask(mask)
+	}
+	q.applyMask(best)
+	q.drawFormat(best)
+	return q, nil
+}
+
+// String returns the symbol drawn with Unicode block characters, two rows of
+// modules per line, surrounded by the 4 modules wide quiet zone. Dark modules
+// are drawn with the block characters, so the code must be shown dark on
+// light, e.g. printed on paper.
+func (q *qrCode) String() string {
+	const quiet = 4
+	dark := func(x, y int) bool {
+		x, y = x-quiet, y-quiet
+		return x >= 0 && x < q.size && y >= 0 && y < q.size && q.modules[y][x]
+	}
+	var b bytes.Buffer
+	n := q.size + 2*quiet
+	for y := 0; y < n; y += 2 {
+		for x := 0; x < n; x++ {
+			switch top, bottom := dark(x, y), dark(x, y+1); {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+type qrBits struct {
+	data []byte
+	n    int
+}
+
+// write appends the n low bits of v, most significant first.
+func (b *qrBits) write(v uint, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if b.n%8 == 0 {
+			b.data = append(b.data, 0)
+		}
+		if v>>uint(i)&1 != 0 {
+			b.data[b.n/8] |= 0x80 >> uint(b.n%8)
+		}
+		b.n++
+	}
+}
+
+func qrGrid(size int) [][]bool {
+	r := make([][]bool, size)
+	for i := range r {
+		r[i] = make([]bool, size)
+	}
+	return r
+}
+
+// qrCodewords splits data into blocks, computes their error correction
+// codewords and interleaves everything.
+func qrCodewords(data []byte, blocks, ec int) []byte {
+	n := len(data) / blocks
+	divisor := qrDivisor(ec)
+	var r []byte
+	for i := 0; i < n; i++ {
+		for j := 0; j < blocks; j++ {
+			r = append(r, data[j*n+i])
+		}
+	}
+	ecc := make([][]byte, blocks)
+	for j := range ecc {
+		ecc[j] = qrRemainder(data[j*n:(j+1)*n], divisor)
+	}
+	for i := 0; i < ec; i++ {
+		for j := range ecc {
+			r = append(r, ecc[j][i])
+		}
+	}
+	return r
+}
+
+// qrMul multiplies in GF(256) modulo x^8 + x^4 + x^3 + x^2 + 1.
+func qrMul(x, y byte) byte {
+	var z byte
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x1d
+		z ^= (y >> uint(i) & 1) * x
+	}
+	return z
+}
+
+// qrDivisor returns the Reed-Solomon generator polynomial of the given
+// degree, (x - 2^0) (x - 2^1) ... (x - 2^(degree-1)), without its leading
+// term and with the highest degree coefficients first.
+func qrDivisor(degree int) []byte {
+	r := make([]byte, degree)
+	r[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range r {
+			r[j] = qrMul(r[j], root)
+			if j+1 < len(r) {
+				r[j] ^= r[j+1]
+			}
+		}
+		root = qrMul(root, 2)
+	}
+	return r
+}
+
+// qrRemainder returns the error correction codewords of data.
+func qrRemainder(data, divisor []byte) []byte {
+	r := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ r[0]
+		copy(r, r[1:])
+		r[len(r)-1] = 0
+		for i, d := range divisor {
+			r[i] ^= qrMul(d, factor)
+		}
+	}
+	return r
+}
+
+func (q *qrCode) set(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.function[y][x] = true
+}
+
+func (q *qrCode) drawFunctionPatterns(version int) {
+	for i := 0; i < q.size; i++ {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+	// The finder patterns, with their separators.
+	for _, c := range [][2]int{{3, 3}, {q.size - 4, 3}, {3, q.size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := c[0]+dx, c[1]+dy
+				if x >= 0 && x < q.size && y >= 0 && y < q.size {
+					d := qrDistance(dx, dy)
+					q.set(x, y, d != 2 && d != 4)
+				}
+			}
+		}
+	}
+	// Up to version 6, there is a single alignment pattern.
+	if version >= 2 {
+		c := q.size - 7
+		for dy := -2; dy <= 2; dy++ {
+			for dx := -2; dx <= 2; dx++ {
+				q.set(c+dx, c+dy, qrDistance(dx, dy) != 1)
+			}
+		}
+	}
+	// Reserve the format information area.
+	q.drawFormat(0)
+}
+
+// qrDistance is the Chebyshev distance from the center of a pattern.
+func qrDistance(dx, dy int) int {
+	if dx < 0 {
+		dx = -dx
+	}
+	if dy < 0 {
+		dy = -dy
+	}
+	if dx > dy {
+		return dx
+	}
+	return dy
+}
+
+// drawFormat draws both copies of the format information, and the dark
+// module next to them.
+func (q *qrCode) drawFormat(mask int) {
+	// Error correction level M is 00, followed by the mask, and a (15, 5)
+	// BCH code.
+	data := uint(mask)
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return bits>>uint(i)&1 != 0 }
+
+	for i := 0; i <= 5; i++ {
+		q.set(8, i, bit(i))
+	}
+	q.set(8, 7, bit(6))
+	q.set(8, 8, bit(7))
+	q.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		q.set(q.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(8, q.size-15+i, bit(i))
+	}
+	q.set(8, q.size-8, true)
+}
+
+// drawCodewords fills the data area in the zigzag order: two columns at a
+// time from the right, alternately upwards and downwards, skipping the
+// vertical timing pattern. Remainder bits are left light.
+func (q *qrCode) drawCodewords(data []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < q.size; vert++ {
+			y := vert
+			if upward {
+				y = q.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if !q.function[y][x] && i < len(data)*8 {
+					q.modules[y][x] = data[i/8]>>uint(7-i%8)&1 != 0
+					i++
+				}
+			}
+		}
+	}
+}
+
+func (q *qrCode) applyMask(mask int) {
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if !q.function[y][x] && qrMask(mask, x, y) {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+func qrMask(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// penalty scores the symbol according to the rules used to pick a mask;
+// lower is better.
+func (q *qrCode) penalty() int {
+	p, dark := 0, 0
+	row, col := make([]bool, q.size), make([]bool, q.size)
+	for i := 0; i < q.size; i++ {
+		for j := 0; j < q.size; j++ {
+			row[j], col[j] = q.modules[i][j], q.modules[j][i]
+			if row[j] {
+				dark++
+			}
+		}
+		p += qrLinePenalty(row) + qrLinePenalty(col)
+	}
+	for y := 0; y+1 < q.size; y++ {
+		for x := 0; x+1 < q.size; x++ {
+			c := q.modules[y][x]
+			if c == q.modules[y][x+1] && c == q.modules[y+1][x] && c == q.modules[y+1][x+1] {
+				p += 3
+			}
+		}
+	}
+	// 10 points for each 5% the proportion of dark modules is away from 50%.
+	total := q.size * q.size
+	balance := dark*20 - total*10
+	if balance < 0 {
+		balance = -balance
+	}
+	return p + 10*(balance/total)
+}
+
+// qrLinePenalty scores runs of 5 or more modules of the same color, and
+// patterns looking like a finder pattern.
+func qrLinePenalty(line []bool) int {
+	p, run := 0, 1
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			run++
+			continue
+		}
+		if run >= 5 {
+			p += run - 2
+		}
+		run = 1
+	}
+
+	finder := []bool{true, false, true, true, true, false, true}
+	light := func(from, to int) bool {
+		for i := from; i < to; i++ {
+			if i >= 0 && i < len(line) && line[i] {
+				return false
+			}
+		}
+		return true
+	}
+	for i := 0; i+len(finder) <= len(line); i++ {
+		match := true
+		for j, f := range finder {
+			if line[i+j] != f {
+				match = false
+				break
+			}
+		}
+		if match && (light(i-4, i) || light(i+7, i+11)) {
+			p += 40
+		}
+	}
+	return p
+}
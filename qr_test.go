@@ -0,0 +1,75 @@
+package gpgeez
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQRCodewords(t *testing.T) {
+	// The HELLO WORLD example from https://www.thonky.com/qr-code-tutorial/
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	ecc := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	assert.Equal(t, append(data, ecc...), qrCodewords(data, 1, 10))
+}
+
+func TestQREncode(t *testing.T) {
+	// Checked against the encoder vendored in npm's qrcode-terminal.
+	expected := []string{
+		"#######.#.....#...###.#######",
+		"#.....#..#........#...#.....#",
+		"#.###.#..#.#.#.#.#....#.###.#",
+		"#.###.#.#..#..#.#.###.#.###.#",
+		"#.###.#.#.#######.#.#.#.###.#",
+		"#.....#.#.##.##...#.#.#.....#",
+		"#######.#.#.#.#.#.#.#.#######",
+		"........#.##..####...........",
+		"#...#.######.##..#.#.#####..#",
+		"#.##...#.##.#..##....#.....#.",
+		".##..#####...###.#....#..#.##",
+		".####.....#..#.###..#.####...",
+		"####.##.###..#.#.#####.###..#",
+		"#..##....#...##...###...####.",
+		"#####.#####.....#..###...####",
+		".#.#.#.##..##.#..#.###...#..#",
+		"##.#..##.#.#.##.#.##..##.###.",
+		"#...#....#......#..###...#.#.",
+		"...#..#..#..####.#..#..#..###",
+		"..##.....#...#.####.....#.#.#",
+		"##.#.##.##...#.....######..#.",
+		"........##..###.#.###...#....",
+		"#######.##.#...#.#.##.#.#...#",
+		"#.....#..#..#.###..##...#.##.",
+		"#.###.#.##..####.#.######.#.#",
+		"#.###.#..#.#.#.##.#####....#.",
+		"#.###.#..###..#.#....##.##.##",
+		"#.....#..#.#.##..##.#....#.#.",
+		"#######.########.####.#..#...",
+	}
+	q, err := qrEncode("OPENPGP4FPR:E58294F2E9A227486E8B061B31CC528FD7FA3F19")
+	assert.Nil(t, err, "qrEncode errored")
+	var rows []string
+	for _, row := range q.modules {
+		var s []byte
+		for _, dark := range row {
+			if dark {
+				s = append(s, '#')
+			} else {
+				s = append(s, '.')
+			}
+		}
+		rows = append(rows, string(s))
+	}
+	assert.Equal(t, expected, rows)
+
+	for n, size := range map[int]int{1: 21, 20: 21, 21: 25, 61: 29, 62: 33, 154: 41} {
+		q, err := qrEncode(strings.Repeat("A", n))
+		assert.Nil(t, err, "qrEncode errored")
+		assert.Equal(t, size, q.size, "wrong size for %d characters", n)
+	}
+	_, err = qrEncode(strings.Repeat("A", 155))
+	assert.NotNil(t, err, "qrEncode accepted too much data")
+	_, err = qrEncode("openpgp4fpr:")
+	assert.NotNil(t, err, "qrEncode accepted lower case letters")
+}
@@ -0,0 +1,96 @@
+package gpgeez
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// RevocationReason is the reason code of a revocation signature, see
+// https://tools.ietf.org/html/rfc4880#section-5.2.3.23
+type RevocationReason uint8
+
+const (
+	RevocationReasonUnspecified      RevocationReason = 0
+	RevocationReasonKeySuperseded    RevocationReason = 1
+	RevocationReasonKeyCompromised   RevocationReason = 2
+	RevocationReasonKeyRetired       RevocationReason = 3
+	RevocationReasonUIDNoLongerValid RevocationReason = 32
+)
+
+// GenerateRevocationCertificate returns a revocation of the whole key, in
+// armored format, like gpg --gen-revoke. It should be created along with
+// the key and stored safely, so that the key can be revoked even if the
+// private key is lost. key itself is not modified.
+func (key *Key) GenerateRevocationCertificate(reason RevocationReason, text string, config *Config) (string, error) {
+	priv, err := checkPrivateKey(key.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+	sig := newRevocation(packet.SigTypeKeyRevocation, priv, reason, text, config)
+	if !sig.Hash.Available() {
+		return "", errors.New("gpgeez: hash function is not available")
+	}
+	h := sig.Hash.New()
+	err = hashKey(h, key.PrimaryKey)
+	if err != nil {
+		return "", err
+	}
+	sig, err = signWithSubpackets(sig, h, priv, nil, config)
+	if err != nil {
+		return "", err
+	}
+
+	buf := new(bytes.Buffer)
+	w, err := config.armorEncode(buf, openpgp.PublicKeyType, map[string]string{"Comment": "This is a revocation certificate"})
+	if err != nil {
+		return "", err
+	}
+	err = sig.Serialize(w)
+	if err != nil {
+		return "", err
+	}
+	w.Close()
+	return buf.String(), nil
+}
+
+// RevokeUID revokes the identity uid, which must be the full user id (e.g.
+// "Joe (test key) <joe@example.com>"). The revocation is added to the
+// identity's signatures.
+func (key *Key) RevokeUID(uid string, reason RevocationReason, text string, config *Config) error {
+	id, ok := key.Identities[uid]
+	if !ok {
+		return errors.New("gpgeez: no such identity " + uid)
+	}
+	priv, err := checkPrivateKey(key.PrivateKey)
+	if err != nil {
+		return err
+	}
+	sig := newRevocation(sigTypeCertificationRevocation, priv, reason, text, config)
+	h, err := hashUserId(id.UserId.Id, key.PrimaryKey, sig)
+	if err != nil {
+		return err
+	}
+	sig, err = signWithSubpackets(sig, h, priv, nil, config)
+	if err != nil {
+		return err
+	}
+	id.Signatures = append(id.Signatures, sig)
+	return nil
+}
+
+// newRevocation returns an unsigned revocation signature made by priv.
+func newRevocation(sigType packet.SignatureType, priv *packet.PrivateKey, reason RevocationReason, text string, config *Config) *packet.Signature {
+	r := uint8(reason)
+	return &packet.Signature{
+		CreationTime:         config.now(),
+		SigType:              sigType,
+		PubKeyAlgo:           priv.PubKeyAlgo,
+		Hash:                 config.Hash(),
+		IssuerKeyId:          &priv.KeyId,
+		RevocationReason:     &r,
+		RevocationReasonText: text,
+	}
+}
@@ -0,0 +1,50 @@
+package gpgeez
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+func TestGenerateRevocationCertificate(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	cert, err := key.GenerateRevocationCertificate(RevocationReasonKeyCompromised, "lost laptop", &config)
+	assert.Nil(t, err, "GenerateRevocationCertificate errored")
+	assert.Equal(t, 0, len(key.Revocations), "key must not be modified")
+
+	// Appending the certificate to the key revokes it.
+	block, err := armor.Decode(strings.NewReader(cert))
+	assert.Nil(t, err)
+	buf := bytes.NewBuffer(key.Keyring())
+	buf.ReadFrom(block.Body)
+	keys, err := ReadKeyRing(buf)
+	assert.Nil(t, err, "ReadKeyRing errored")
+	assert.Equal(t, 1, len(keys[0].Revocations))
+	assert.Equal(t, uint8(RevocationReasonKeyCompromised), *keys[0].Revocations[0].RevocationReason)
+	assert.Equal(t, "lost laptop", keys[0].Revocations[0].RevocationReasonText)
+}
+
+func TestRevokeUID(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	uid := "Joe (test key) <joe@example.com>"
+	assert.Nil(t, key.RevokeUID(uid, RevocationReasonUIDNoLongerValid, "", &config))
+	assert.NotNil(t, key.RevokeUID("Jane", RevocationReasonUIDNoLongerValid, "", &config))
+
+	key, err = ImportPublicKey(mustArmor(t, key))
+	assert.Nil(t, err, "ImportPublicKey errored")
+	sigs := key.Identities[uid].Signatures
+	assert.Equal(t, 1, len(sigs))
+	assert.Equal(t, sigTypeCertificationRevocation, sigs[0].SigType)
+	assert.Equal(t, uint8(RevocationReasonUIDNoLongerValid), *sigs[0].RevocationReason)
+	assert.Nil(t, key.PrimaryKey.VerifyUserIdSignature(uid, key.PrimaryKey, sigs[0]))
+}
@@ -0,0 +1,139 @@
+package gpgeez
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/openpgp/packet"
+	"golang.org/x/crypto/openpgp/s2k"
+)
+
+// serializePrivate writes the key, including private key material, to w.
+//
+// Unlike openpgp.Entity.SerializePrivate, the existing signatures are written
+// as-is instead of being signed again. Signing them again would lose any
+// subpackets which the vendored openpgp package doesn't know how to write
+// (see signWithSubpackets), as well as third-party certifications.
+func (key *Key) serializePrivate(w io.Writer) error {
+	return key.serializeProtected(w, nil, nil)
+}
+
+// serializeProtected is like serializePrivate, except that the private key
+// material is encrypted with passphrase, unless passphrase is nil.
+func (key *Key) serializeProtected(w io.Writer, passphrase []byte, config *Config) error {
+	err := writePrivateKey(w, key.PrivateKey, passphrase, config)
+	if err != nil {
+		return err
+	}
+	for _, id := range key.Identities {
+		err = id.UserId.Serialize(w)
+		if err != nil {
+			return err
+		}
+		err = id.SelfSignature.Serialize(w)
+		if err != nil {
+			return err
+		}
+		for _, sig := range id.Signatures {
+			err = sig.Serialize(w)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for _, subkey := range key.Subkeys {
+		if subkey.PrivateKey != nil {
+			err = writePrivateKey(w, subkey.PrivateKey, passphrase, config)
+		} else {
+			err = subkey.PublicKey.Serialize(w)
+		}
+		if err != nil {
+			return err
+		}
+		err = subkey.Sig.Serialize(w)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writePrivateKey writes priv to w, encrypted with passphrase unless it is
+// nil. The vendored openpgp package can only write unencrypted private keys,
+// so the secret key packet is rebuilt here, using an iterated and salted S2K
+// and a SHA-1 checksum, see https://tools.ietf.org/html/rfc4880#section-5.5.3
+func writePrivateKey(w io.Writer, priv *packet.PrivateKey, passphrase []byte, config *Config) error {
+	if passphrase == nil {
+		return priv.Serialize(w)
+	}
+	_, err := checkPrivateKey(priv)
+	if err != nil {
+		return err
+	}
+	cipherFunc := config.Cipher()
+	switch cipherFunc {
+	case packet.CipherAES128, packet.CipherAES192, packet.CipherAES256:
+	default:
+		return errors.New("gpgeez: only AES is supported to protect private keys")
+	}
+
+	buf := new(bytes.Buffer)
+	err = priv.Serialize(buf)
+	if err != nil {
+		return err
+	}
+	body, err := packetBody(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	buf = new(bytes.Buffer)
+	err = priv.PublicKey.Serialize(buf)
+	if err != nil {
+		return err
+	}
+	public, err := packetBody(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	// body is the public key, a zero s2k usage octet, the secret key
+	// material and a two octet checksum.
+	secret := body[len(public)+1 : len(body)-2]
+
+	protected := new(bytes.Buffer)
+	protected.Write(public)
+	protected.Write([]byte{254, byte(cipherFunc)})
+	k := make([]byte, cipherFunc.KeySize())
+	err = s2k.Serialize(protected, k, config.Random(), passphrase, &s2k.Config{Hash: config.Hash(), S2KCount: config.PacketConfig().S2KCount})
+	if err != nil {
+		return err
+	}
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return err
+	}
+	iv := make([]byte, block.BlockSize())
+	_, err = io.ReadFull(config.Random(), iv)
+	if err != nil {
+		return err
+	}
+	protected.Write(iv)
+	h := crypto.SHA1.New()
+	h.Write(secret)
+	data := h.Sum(append([]byte{}, secret...))
+	cipher.NewCFBEncrypter(block, iv).XORKeyStream(data, data)
+	protected.Write(data)
+
+	tag := byte(5)
+	if priv.IsSubkey {
+		tag = 7
+	}
+	out := new(bytes.Buffer)
+	serializePacketHeader(out, tag, protected.Len())
+	out.Write(protected.Bytes())
+	_, err = w.Write(out.Bytes())
+	return err
+}
@@ -0,0 +1,195 @@
+package gpgeez
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strconv"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+// privateKeyShareType is the armor type for a share of a private key.
+const privateKeyShareType = "PGP PRIVATE KEY SHARE"
+
+// SplitPrivateKey splits the private part of a key into n shares, any k of which
+// can be combined with CombinePrivateKeyShares to recover the key. This uses
+// Shamir's secret sharing over GF(256), one byte at a time.
+//
+// Each share is returned in armored format, with Share-Index and
+// Share-Threshold headers. As with ArmorPrivate, the shares are not protected
+// by a passphrase, so the private key must be present and decrypted.
+func (key *Key) SplitPrivateKey(n, k int, config *Config) ([]string, error) {
+	if k < 2 || n < k || n > 255 {
+		return nil, errors.New("gpgeez: invalid share parameters, need 2 <= k <= n <= 255")
+	}
+	if _, err := checkPrivateKey(key.PrivateKey); err != nil {
+		return nil, err
+	}
+	secret := new(bytes.Buffer)
+	if err := key.serializePrivate(secret); err != nil {
+		return nil, err
+	}
+	shares, err := shamirSplit(secret.Bytes(), n, k, config.Random())
+	if err != nil {
+		return nil, err
+	}
+
+	r := make([]string, n)
+	for i, share := range shares {
+		buf := new(bytes.Buffer)
+		headers := config.armorHeaders()
+		headers["Share-Index"] = strconv.Itoa(int(share[0]))
+		headers["Share-Threshold"] = strconv.Itoa(k)
+		armor, err := config.armorEncode(buf, privateKeyShareType, headers)
+		if err != nil {
+			return nil, err
+		}
+		armor.Write(share)
+		armor.Close()
+		r[i] = buf.String()
+	}
+	return r, nil
+}
+
+// CombinePrivateKeyShares recovers a key from shares created by
+// SplitPrivateKey. At least as many shares as the threshold used when
+// splitting the key must be provided.
+func CombinePrivateKeyShares(shares []string) (*Key, error) {
+	threshold := 0
+	points := make(map[byte][]byte)
+	for _, s := range shares {
+		block, err := armor.Decode(bytes.NewBufferString(s))
+		if err != nil {
+			return nil, err
+		}
+		if block.Type != privateKeyShareType {
+			return nil, errors.New("gpgeez: unexpected armor type " + block.Type)
+		}
+		k, err := strconv.Atoi(block.Header["Share-Threshold"])
+		if err != nil {
+			return nil, errors.New("gpgeez: invalid Share-Threshold header")
+		}
+		if threshold != 0 && k != threshold {
+			return nil, errors.New("gpgeez: shares have different thresholds")
+		}
+		threshold = k
+
+		share, err := ioutil.ReadAll(block.Body)
+		if err != nil {
+			return nil, err
+		}
+		if len(share) < 2 || block.Header["Share-Index"] != strconv.Itoa(int(share[0])) {
+			return nil, errors.New("gpgeez: malformed share")
+		}
+		points[share[0]] = share[1:]
+	}
+	if threshold == 0 || len(points) < threshold {
+		return nil, errors.New("gpgeez: not enough shares to recover the key")
+	}
+
+	secret, err := shamirCombine(points)
+	if err != nil {
+		return nil, err
+	}
+	el, err := openpgp.ReadKeyRing(bytes.NewReader(secret))
+	if err != nil {
+		return nil, err
+	}
+	return &Key{*el[0]}, nil
+}
+
+// shamirSplit returns n shares of secret. Each share is the share's x
+// coordinate followed by the value of a random polynomial of degree k-1 at x,
+// for each byte of secret.
+func shamirSplit(secret []byte, n, k int, rand io.Reader) ([][]byte, error) {
+	shares := make([][]byte, n)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret)+1)
+		shares[i][0] = byte(i + 1)
+	}
+
+	coefficients := make([]byte, k)
+	for j, b := range secret {
+		coefficients[0] = b
+		_, err := io.ReadFull(rand, coefficients[1:])
+		if err != nil {
+			return nil, err
+		}
+		for _, share := range shares {
+			share[j+1] = gfEval(coefficients, share[0])
+		}
+	}
+	return shares, nil
+}
+
+// shamirCombine recovers the secret from points, using Lagrange interpolation
+// at x = 0.
+func shamirCombine(points map[byte][]byte) ([]byte, error) {
+	length := -1
+	for x, y := range points {
+		if x == 0 {
+			return nil, errors.New("gpgeez: invalid share index")
+		}
+		if length != -1 && len(y) != length {
+			return nil, errors.New("gpgeez: shares have different lengths")
+		}
+		length = len(y)
+	}
+
+	secret := make([]byte, length)
+	for xi, yi := range points {
+		// basis = prod(xj / (xj - xi)) for j != i. Subtraction is xor.
+		basis := byte(1)
+		for xj := range points {
+			if xj != xi {
+				basis = gfMul(basis, gfDiv(xj, xj^xi))
+			}
+		}
+		for j := range secret {
+			secret[j] ^= gfMul(yi[j], basis)
+		}
+	}
+	return secret, nil
+}
+
+// gfEval evaluates the polynomial with the given coefficients at x, using
+// Horner's method.
+func gfEval(coefficients []byte, x byte) byte {
+	r := byte(0)
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		r = gfMul(r, x) ^ coefficients[i]
+	}
+	return r
+}
+
+// Log and exp tables for GF(256) with the AES polynomial x^8+x^4+x^3+x+1,
+// using 3 as the generator.
+var gfLog, gfExp [256]byte
+
+func init() {
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		gfExp[i] = x
+		gfLog[x] = byte(i)
+		// x *= 3
+		x ^= x<<1 ^ byte(int8(x)>>7)&0x1b
+	}
+	gfExp[255] = gfExp[0]
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[(int(gfLog[a])+int(gfLog[b]))%255]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[(int(gfLog[a])-int(gfLog[b])+255)%255]
+}
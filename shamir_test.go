@@ -0,0 +1,47 @@
+package gpgeez
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitPrivateKey(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	shares, err := key.SplitPrivateKey(5, 3, &config)
+	assert.Nil(t, err, "SplitPrivateKey errored")
+	assert.Equal(t, 5, len(shares))
+
+	recovered, err := CombinePrivateKeyShares([]string{shares[4], shares[0], shares[2]})
+	assert.Nil(t, err, "CombinePrivateKeyShares errored")
+	assert.Equal(t, key.PrimaryKey.Fingerprint, recovered.PrimaryKey.Fingerprint)
+	assert.NotNil(t, recovered.PrivateKey)
+
+	_, err = CombinePrivateKeyShares(shares[:2])
+	assert.NotNil(t, err, "two shares should not be enough")
+}
+
+func TestSplitPrivateKeyErrors(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	public, err := ImportPublicKey(mustArmor(t, key))
+	assert.Nil(t, err, "ImportPublicKey errored")
+	_, err = public.SplitPrivateKey(5, 3, &config)
+	assert.NotNil(t, err, "public keys can't be split")
+
+	buf := new(bytes.Buffer)
+	assert.Nil(t, key.serializeProtected(buf, []byte("passphrase"), &config))
+	encrypted, private, err := ParseKey(buf.Bytes())
+	assert.Nil(t, err, "ParseKey errored")
+	assert.True(t, private)
+	assert.True(t, encrypted.PrivateKey.Encrypted)
+	_, err = encrypted.SplitPrivateKey(5, 3, &config)
+	assert.NotNil(t, err, "encrypted keys can't be split")
+}
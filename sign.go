@@ -0,0 +1,253 @@
+package gpgeez
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+	"golang.org/x/crypto/openpgp/s2k"
+)
+
+// ErrSignatureTooFuture is returned by Verify when a signature was created
+// further in the future than Config.ClockSkewTolerance allows.
+var ErrSignatureTooFuture = errors.New("gpgeez: signature creation time is in the future")
+
+const defaultClockSkewTolerance = 5 * time.Minute
+
+// Sign returns a binary detached signature of the data read from r. The hash
+// function is config.HashAlgorithmFor[HashForDataSignature], if set.
+func (key *Key) Sign(r io.Reader, config *Config) ([]byte, error) {
+	priv, err := key.signingKey(config.now())
+	if err != nil {
+		return nil, err
+	}
+	return detachSign(priv, packet.SigTypeBinary, config.hashFor(HashForDataSignature), r, config)
+}
+
+// SignWithHash is like Sign, but uses the hash function h instead of the one
+// from config, e.g. to require SHA-512 for code signing. MD5 and SHA-1 are
+// rejected unless config.AllowWeakAlgorithms is set.
+func (key *Key) SignWithHash(r io.Reader, h crypto.Hash, config *Config) ([]byte, error) {
+	err := config.checkHash(h)
+	if err != nil {
+		return nil, err
+	}
+	priv, err := key.signingKey(config.now())
+	if err != nil {
+		return nil, err
+	}
+	return detachSign(priv, packet.SigTypeBinary, h, r, config)
+}
+
+// MultiSign signs each reader in files independently, and returns a map from
+// the same names to binary detached signatures. The signing key is only
+// selected once, which makes this cheaper than calling Sign in a loop.
+func (key *Key) MultiSign(files map[string]io.Reader, config *Config) (map[string][]byte, error) {
+	priv, err := key.signingKey(config.now())
+	if err != nil {
+		return nil, err
+	}
+	sigs := make(map[string][]byte, len(files))
+	for name, r := range files {
+		sig, err := detachSign(priv, packet.SigTypeBinary, config.hashFor(HashForDataSignature), r, config)
+		if err != nil {
+			return nil, err
+		}
+		sigs[name] = sig
+	}
+	return sigs, nil
+}
+
+// Verify checks that sig is a valid binary detached signature of the data read
+// from r, made by key. Signatures using MD5 or SHA-1, or made by a weak key,
+// are rejected unless config.AllowWeakAlgorithms is set. Signatures created
+// in the future, beyond config.ClockSkewTolerance, are rejected with
+// ErrSignatureTooFuture.
+func (key *Key) Verify(r io.Reader, sig []byte, config *Config) error {
+	err := key.verify(r, sig, config)
+	if err != nil {
+		config.log(slog.LevelWarn, "verification failed", "fingerprint", key.Fingerprint(), "error", err)
+		return err
+	}
+	config.log(slog.LevelInfo, "verification passed", "fingerprint", key.Fingerprint())
+	return nil
+}
+
+func (key *Key) verify(r io.Reader, sig []byte, config *Config) error {
+	s, err := parseSignature(sig)
+	if err != nil {
+		return err
+	}
+	if s.CreationTime.After(config.now().Add(config.clockSkewTolerance())) {
+		return ErrSignatureTooFuture
+	}
+	err = config.checkHash(s.Hash)
+	if err != nil {
+		return err
+	}
+	keyring := openpgp.EntityList{&key.Entity}
+	if s.IssuerKeyId != nil {
+		for _, k := range keyring.KeysById(*s.IssuerKeyId) {
+			err = config.checkKeyStrength(k.PublicKey)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	_, err = openpgp.CheckDetachedSignature(keyring, r, bytes.NewReader(sig))
+	return err
+}
+
+// VerifySignedAfter is like Verify with the default Config, but also rejects
+// signatures created before notBefore. This prevents an old signature, which
+// is still valid, from being replayed, e.g. for a signed deployment manifest.
+func (key *Key) VerifySignedAfter(data io.Reader, sig []byte, notBefore time.Time) error {
+	err := key.Verify(data, sig, &Config{})
+	if err != nil {
+		return err
+	}
+	s, err := parseSignature(sig)
+	if err != nil {
+		return err
+	}
+	if s.CreationTime.Before(notBefore) {
+		return fmt.Errorf("gpgeez: signature was created at %v, before %v", s.CreationTime, notBefore)
+	}
+	return nil
+}
+
+// ErrNoSigningKey is returned when a key has no private material to sign
+// with.
+var ErrNoSigningKey = errors.New("gpgeez: no signing key")
+
+// ActiveSigningSubkey returns the private key to sign with: the most recently
+// created signing subkey that is neither expired, revoked nor encrypted, or
+// the primary key if no subkey qualifies. The primary key must then be
+// decrypted.
+func (key *Key) ActiveSigningSubkey() (*packet.PrivateKey, error) {
+	return key.signingKey(time.Now())
+}
+
+// signingKey returns the private key to sign data with at now, see
+// ActiveSigningSubkey.
+func (key *Key) signingKey(now time.Time) (*packet.PrivateKey, error) {
+	var r *packet.PrivateKey
+	for _, subkey := range key.Subkeys {
+		if !subkey.Sig.FlagsValid || !subkey.Sig.FlagSign ||
+			!subkey.PublicKey.PubKeyAlgo.CanSign() || !isActiveSubkey(subkey, now) ||
+			subkey.PrivateKey == nil || subkey.PrivateKey.Encrypted {
+			continue
+		}
+		if r == nil || !subkey.PublicKey.CreationTime.Before(r.CreationTime) {
+			r = subkey.PrivateKey
+		}
+	}
+	if r != nil {
+		return r, nil
+	}
+	if key.PrivateKey == nil {
+		return nil, ErrNoSigningKey
+	}
+	return checkPrivateKey(key.PrivateKey)
+}
+
+func checkPrivateKey(priv *packet.PrivateKey) (*packet.PrivateKey, error) {
+	if priv == nil {
+		return nil, errors.New("gpgeez: key has no private material")
+	}
+	if priv.Encrypted {
+		return nil, errors.New("gpgeez: private key must be decrypted")
+	}
+	return priv, nil
+}
+
+// detachSign returns a signature of type sigType over the data read from r,
+// made by priv with hash h.
+func detachSign(priv *packet.PrivateKey, sigType packet.SignatureType, h crypto.Hash, r io.Reader, config *Config) ([]byte, error) {
+	if _, ok := s2k.HashToHashId(h); !ok || !h.Available() {
+		return nil, fmt.Errorf("gpgeez: hash function %v is not supported", h)
+	}
+	sig := &packet.Signature{
+		SigType:      sigType,
+		PubKeyAlgo:   priv.PubKeyAlgo,
+		Hash:         h,
+		CreationTime: config.now(),
+		IssuerKeyId:  &priv.KeyId,
+	}
+	digest := h.New()
+	_, err := io.Copy(digest, r)
+	if err != nil {
+		return nil, err
+	}
+	err = sig.Sign(digest, priv, config.PacketConfig())
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	err = sig.Serialize(buf)
+	if err != nil {
+		return nil, err
+	}
+	config.log(slog.LevelInfo, "signature created",
+		"key_id", priv.KeyIdString(), "type", int(sigType), "hash", h.String())
+	return buf.Bytes(), nil
+}
+
+// parseSignature parses a single binary signature packet.
+func parseSignature(sig []byte) (*packet.Signature, error) {
+	p, err := packet.Read(bytes.NewReader(sig))
+	if err != nil {
+		return nil, err
+	}
+	s, ok := p.(*packet.Signature)
+	if !ok {
+		return nil, errors.New("gpgeez: invalid signature")
+	}
+	return s, nil
+}
+
+// SignatureType returns the type of a binary signature packet, without
+// verifying it. For instance, detached signatures are packet.SigTypeBinary,
+// and timestamps created by TimestampSignature are 0x40.
+func SignatureType(sig []byte) (packet.SignatureType, error) {
+	s, err := parseSignature(sig)
+	if err != nil {
+		return 0, err
+	}
+	return s.SigType, nil
+}
+
+// SignatureCreationTime returns the creation time of a binary signature
+// packet, e.g. to log when a detached signature was made. The signature is not
+// verified.
+func SignatureCreationTime(sig []byte) (time.Time, error) {
+	s, err := parseSignature(sig)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return s.CreationTime, nil
+}
+
+// SameIssuer returns whether two binary signature packets name the same
+// issuer key id, e.g. to group signatures by key. The signatures are not
+// verified. If either signature has no issuer, SameIssuer returns false.
+func SameIssuer(sig1, sig2 []byte) (bool, error) {
+	s1, err := parseSignature(sig1)
+	if err != nil {
+		return false, err
+	}
+	s2, err := parseSignature(sig2)
+	if err != nil {
+		return false, err
+	}
+	if s1.IssuerKeyId == nil || s2.IssuerKeyId == nil {
+		return false, nil
+	}
+	return *s1.IssuerKeyId == *s2.IssuerKeyId, nil
+}
@@ -0,0 +1,189 @@
+package gpgeez
+
+import (
+	"bytes"
+	"crypto"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+func TestMultiSign(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	files := map[string]io.Reader{
+		"a.txt": strings.NewReader("hello"),
+		"b.txt": strings.NewReader("world"),
+	}
+	sigs, err := key.MultiSign(files, &config)
+	assert.Nil(t, err, "MultiSign errored")
+	assert.Equal(t, 2, len(sigs))
+	assert.Nil(t, key.Verify(strings.NewReader("hello"), sigs["a.txt"], &config))
+	assert.Nil(t, key.Verify(strings.NewReader("world"), sigs["b.txt"], &config))
+	assert.NotNil(t, key.Verify(strings.NewReader("world"), sigs["a.txt"], &config))
+
+	sig, err := key.Sign(bytes.NewBufferString("hello"), &config)
+	assert.Nil(t, err, "Sign errored")
+	assert.Nil(t, key.Verify(strings.NewReader("hello"), sig, &config))
+}
+
+func TestSignatureType(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	sig, err := key.Sign(strings.NewReader("hello"), &config)
+	assert.Nil(t, err, "Sign errored")
+	sigType, err := SignatureType(sig)
+	assert.Nil(t, err, "SignatureType errored")
+	assert.Equal(t, packet.SignatureType(packet.SigTypeBinary), sigType)
+
+	sig, err = key.TimestampSignature([]byte("hello"), &config)
+	assert.Nil(t, err, "TimestampSignature errored")
+	sigType, err = SignatureType(sig)
+	assert.Nil(t, err, "SignatureType errored")
+	assert.Equal(t, sigTypeTimestamp, sigType)
+
+	_, err = SignatureType(key.Keyring())
+	assert.NotNil(t, err)
+}
+
+func TestSignatureCreationTime(t *testing.T) {
+	created := time.Unix(1500000000, 0)
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	config.Now = func() time.Time { return created }
+	sig, err := key.Sign(strings.NewReader("hello"), &config)
+	assert.Nil(t, err, "Sign errored")
+	ts, err := SignatureCreationTime(sig)
+	assert.Nil(t, err, "SignatureCreationTime errored")
+	assert.Equal(t, created, ts)
+
+	_, err = SignatureCreationTime(key.Keyring())
+	assert.NotNil(t, err)
+}
+
+func TestSignWithHash(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	sig, err := key.SignWithHash(strings.NewReader("hello"), crypto.SHA512, &config)
+	assert.Nil(t, err, "SignWithHash errored")
+	s, err := parseSignature(sig)
+	assert.Nil(t, err)
+	assert.Equal(t, crypto.SHA512, s.Hash)
+	assert.Nil(t, key.Verify(strings.NewReader("hello"), sig, &config))
+
+	_, err = key.SignWithHash(strings.NewReader("hello"), crypto.SHA1, &config)
+	assert.NotNil(t, err, "SHA-1 must be rejected")
+	config.AllowWeakAlgorithms = true
+	_, err = key.SignWithHash(strings.NewReader("hello"), crypto.SHA1, &config)
+	assert.Nil(t, err, "SHA-1 must be allowed with AllowWeakAlgorithms")
+	_, err = key.SignWithHash(strings.NewReader("hello"), crypto.SHA3_256, &config)
+	assert.NotNil(t, err, "hashes without an OpenPGP id must be rejected")
+}
+
+func TestSameIssuer(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	other, err := CreateKey("Joe", "other key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	sig1, err := key.Sign(strings.NewReader("hello"), &config)
+	assert.Nil(t, err, "Sign errored")
+	sig2, err := key.Sign(strings.NewReader("world"), &config)
+	assert.Nil(t, err, "Sign errored")
+	sig3, err := other.Sign(strings.NewReader("hello"), &config)
+	assert.Nil(t, err, "Sign errored")
+
+	same, err := SameIssuer(sig1, sig2)
+	assert.Nil(t, err, "SameIssuer errored")
+	assert.True(t, same)
+	same, err = SameIssuer(sig1, sig3)
+	assert.Nil(t, err, "SameIssuer errored")
+	assert.False(t, same)
+
+	// A signature without an issuer subpacket.
+	s := &packet.Signature{
+		SigType:      packet.SigTypeBinary,
+		PubKeyAlgo:   key.PrivateKey.PubKeyAlgo,
+		Hash:         crypto.SHA256,
+		CreationTime: time.Now(),
+	}
+	h := crypto.SHA256.New()
+	h.Write([]byte("hello"))
+	assert.Nil(t, s.Sign(h, key.PrivateKey, nil))
+	buf := new(bytes.Buffer)
+	assert.Nil(t, s.Serialize(buf))
+	same, err = SameIssuer(sig1, buf.Bytes())
+	assert.Nil(t, err, "SameIssuer errored")
+	assert.False(t, same)
+
+	_, err = SameIssuer(sig1, key.Keyring())
+	assert.NotNil(t, err)
+}
+
+func TestVerifySignedAfter(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	signed := time.Now().Add(-time.Hour).Truncate(time.Second)
+	config.Now = func() time.Time { return signed }
+	sig, err := key.Sign(strings.NewReader("hello"), &config)
+	assert.Nil(t, err, "Sign errored")
+
+	assert.Nil(t, key.VerifySignedAfter(strings.NewReader("hello"), sig, signed.Add(-time.Minute)))
+	assert.Nil(t, key.VerifySignedAfter(strings.NewReader("hello"), sig, signed))
+	assert.NotNil(t, key.VerifySignedAfter(strings.NewReader("hello"), sig, signed.Add(time.Minute)))
+	assert.NotNil(t, key.VerifySignedAfter(strings.NewReader("world"), sig, signed.Add(-time.Minute)))
+}
+
+func TestClockSkewTolerance(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	signer := config
+	signer.Now = func() time.Time { return time.Now().Add(time.Minute) }
+	sig, err := key.Sign(strings.NewReader("hello"), &signer)
+	assert.Nil(t, err, "Sign errored")
+	assert.Nil(t, key.Verify(strings.NewReader("hello"), sig, &config))
+
+	signer.Now = func() time.Time { return time.Now().Add(time.Hour) }
+	sig, err = key.Sign(strings.NewReader("hello"), &signer)
+	assert.Nil(t, err, "Sign errored")
+	assert.Equal(t, ErrSignatureTooFuture, key.Verify(strings.NewReader("hello"), sig, &config))
+	config.ClockSkewTolerance = 2 * time.Hour
+	assert.Nil(t, key.Verify(strings.NewReader("hello"), sig, &config))
+}
+
+func TestActiveSigningSubkey(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	priv, err := key.ActiveSigningSubkey()
+	assert.Nil(t, err, "ActiveSigningSubkey errored")
+	assert.Equal(t, key.PrivateKey, priv, "there is no signing subkey")
+
+	// Turn the RSA subkey into a signing subkey.
+	sig := *key.Subkeys[0].Sig
+	sig.FlagSign = true
+	key.Subkeys[0].Sig = &sig
+	priv, err = key.ActiveSigningSubkey()
+	assert.Nil(t, err, "ActiveSigningSubkey errored")
+	assert.Equal(t, key.Subkeys[0].PrivateKey, priv)
+
+	public, err := ImportPublicKey(mustArmor(t, key))
+	assert.Nil(t, err, "ImportPublicKey errored")
+	_, err = public.ActiveSigningSubkey()
+	assert.Equal(t, ErrNoSigningKey, err)
+}
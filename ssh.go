@@ -0,0 +1,282 @@
+package gpgeez
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"math/big"
+)
+
+// The OpenSSH private key format is described in
+// https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.key
+const sshPrivateKeyMagic = "openssh-key-v1\x00"
+
+// MarshalSSHPrivateKey returns the primary private key in the unencrypted
+// OpenSSH private key format, PEM encoded. This lets the same signing key be
+// used with ssh(1).
+//
+// RSA and ECDSA keys are supported. The private key must have been decrypted.
+// The comment is the primary user id, or empty if the key has none.
+func (key *Key) MarshalSSHPrivateKey() ([]byte, error) {
+	if key.PrivateKey == nil {
+		return nil, errors.New("gpgeez: key has no private material")
+	}
+	if key.PrivateKey.Encrypted {
+		return nil, errors.New("gpgeez: private key must be decrypted")
+	}
+
+	var pub, priv sshBuffer
+	switch k := key.PrivateKey.PrivateKey.(type) {
+	case *rsa.PrivateKey:
+		pub.writeString([]byte("ssh-rsa"))
+		pub.writeMPInt(big.NewInt(int64(k.E)))
+		pub.writeMPInt(k.N)
+
+		priv.writeString([]byte("ssh-rsa"))
+		priv.writeMPInt(k.N)
+		priv.writeMPInt(big.NewInt(int64(k.E)))
+		priv.writeMPInt(k.D)
+		priv.writeMPInt(new(big.Int).ModInverse(k.Primes[1], k.Primes[0]))
+		priv.writeMPInt(k.Primes[0])
+		priv.writeMPInt(k.Primes[1])
+	case *ecdsa.PrivateKey:
+		keyType, curve, err := sshCurveName(k.Curve)
+		if err != nil {
+			return nil, err
+		}
+		point := elliptic.Marshal(k.Curve, k.X, k.Y)
+		pub.writeString([]byte(keyType))
+		pub.writeString([]byte(curve))
+		pub.writeString(point)
+
+		priv.writeString([]byte(keyType))
+		priv.writeString([]byte(curve))
+		priv.writeString(point)
+		priv.writeMPInt(k.D)
+	default:
+		return nil, errors.New("gpgeez: unsupported key algorithm for OpenSSH export")
+	}
+	var comment string
+	if id := key.primaryIdentity(); id != nil {
+		comment = id.UserId.Id
+	}
+	priv.writeString([]byte(comment))
+
+	// The private section starts with a random check value (repeated twice),
+	// and is padded to the cipher block size, which is 8 for "none".
+	var check [4]byte
+	_, err := rand.Read(check[:])
+	if err != nil {
+		return nil, err
+	}
+	var section sshBuffer
+	section.Write(check[:])
+	section.Write(check[:])
+	section.Write(priv.Bytes())
+	for i := byte(1); section.Len()%8 != 0; i++ {
+		section.WriteByte(i)
+	}
+
+	var out sshBuffer
+	out.WriteString(sshPrivateKeyMagic)
+	out.writeString([]byte("none")) // cipher
+	out.writeString([]byte("none")) // kdf
+	out.writeString(nil)            // kdf options
+	out.writeUint32(1)              // number of keys
+	out.writeString(pub.Bytes())
+	out.writeString(section.Bytes())
+
+	return pem.EncodeToMemory(&pem.Block{Type: "OPENSSH PRIVATE KEY", Bytes: out.Bytes()}), nil
+}
+
+// ImportSSHPrivateKey creates a key whose primary key is the private key in
+// pemBlock, which can be in the OpenSSH format or in the older PEM formats
+// ("RSA PRIVATE KEY" and "EC PRIVATE KEY"). The key gets a fresh self-signed
+// identity and a new encryption subkey, generated according to config.
+//
+// Only unencrypted RSA and ECDSA keys on the NIST curves are supported. The
+// vendored openpgp package cannot create ECDH or Curve25519 keys, so the
+// subkey is of type config.SubkeyType (RSA by default).
+func ImportSSHPrivateKey(pemBlock []byte, name, comment, email string, config *Config) (*Key, error) {
+	block, _ := pem.Decode(pemBlock)
+	if block == nil {
+		return nil, errors.New("gpgeez: no PEM block found")
+	}
+	if block.Headers["Proc-Type"] != "" {
+		return nil, errors.New("gpgeez: encrypted SSH keys are not supported")
+	}
+
+	var priv interface{}
+	var err error
+	switch block.Type {
+	case "OPENSSH PRIVATE KEY":
+		priv, err = parseSSHPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		priv, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		priv, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		err = errors.New("gpgeez: unsupported PEM block type " + block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return importPrivateKey(priv, name, comment, email, config)
+}
+
+// parseSSHPrivateKey parses the output of MarshalSSHPrivateKey, or of
+// ssh-keygen without a passphrase.
+func parseSSHPrivateKey(data []byte) (interface{}, error) {
+	if !bytes.HasPrefix(data, []byte(sshPrivateKeyMagic)) {
+		return nil, errors.New("gpgeez: invalid OpenSSH private key")
+	}
+	r := &sshReader{data: data[len(sshPrivateKeyMagic):]}
+	cipher := string(r.readString())
+	kdf := string(r.readString())
+	r.readString() // kdf options
+	if cipher != "none" || kdf != "none" {
+		return nil, errors.New("gpgeez: encrypted SSH keys are not supported")
+	}
+	if r.readUint32() != 1 {
+		return nil, errors.New("gpgeez: only OpenSSH files with a single key are supported")
+	}
+	r.readString() // public key, repeated in the private section
+	r = &sshReader{data: r.readString(), err: r.err}
+	if r.readUint32() != r.readUint32() {
+		return nil, errors.New("gpgeez: invalid OpenSSH private key")
+	}
+
+	var priv interface{}
+	switch keyType := string(r.readString()); keyType {
+	case "ssh-rsa":
+		k := new(rsa.PrivateKey)
+		k.N = r.readMPInt()
+		k.E = int(r.readMPInt().Int64())
+		k.D = r.readMPInt()
+		r.readMPInt() // iqmp
+		k.Primes = []*big.Int{r.readMPInt(), r.readMPInt()}
+		if r.err != nil {
+			return nil, r.err
+		}
+		if err := k.Validate(); err != nil {
+			return nil, err
+		}
+		k.Precompute()
+		priv = k
+	case "ecdsa-sha2-nistp256", "ecdsa-sha2-nistp384", "ecdsa-sha2-nistp521":
+		var curve elliptic.Curve
+		switch string(r.readString()) {
+		case "nistp256":
+			curve = elliptic.P256()
+		case "nistp384":
+			curve = elliptic.P384()
+		case "nistp521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.New("gpgeez: unsupported elliptic curve")
+		}
+		k := new(ecdsa.PrivateKey)
+		k.Curve = curve
+		k.X, k.Y = elliptic.Unmarshal(curve, r.readString())
+		k.D = r.readMPInt()
+		if r.err != nil {
+			return nil, r.err
+		}
+		if k.X == nil {
+			return nil, errors.New("gpgeez: invalid elliptic curve point")
+		}
+		if k.D.Sign() <= 0 || k.D.Cmp(curve.Params().N) >= 0 {
+			return nil, errors.New("gpgeez: invalid ECDSA private scalar")
+		}
+		if x, y := curve.ScalarBaseMult(k.D.Bytes()); x.Cmp(k.X) != 0 || y.Cmp(k.Y) != 0 {
+			return nil, errors.New("gpgeez: ECDSA private key doesn't match its public key")
+		}
+		priv = k
+	default:
+		return nil, errors.New("gpgeez: unsupported SSH key type " + keyType)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return priv, nil
+}
+
+func sshCurveName(curve elliptic.Curve) (keyType, name string, err error) {
+	switch curve {
+	case elliptic.P256():
+		name = "nistp256"
+	case elliptic.P384():
+		name = "nistp384"
+	case elliptic.P521():
+		name = "nistp521"
+	default:
+		return "", "", errors.New("gpgeez: unsupported elliptic curve")
+	}
+	return "ecdsa-sha2-" + name, name, nil
+}
+
+// sshBuffer writes the data types from https://tools.ietf.org/html/rfc4251#section-5
+type sshBuffer struct {
+	bytes.Buffer
+}
+
+func (b *sshBuffer) writeUint32(n uint32) {
+	binary.Write(b, binary.BigEndian, n)
+}
+
+func (b *sshBuffer) writeString(s []byte) {
+	b.writeUint32(uint32(len(s)))
+	b.Write(s)
+}
+
+func (b *sshBuffer) writeMPInt(n *big.Int) {
+	bytes := n.Bytes()
+	if len(bytes) > 0 && bytes[0]&0x80 != 0 {
+		bytes = append([]byte{0}, bytes...)
+	}
+	b.writeString(bytes)
+}
+
+// sshReader reads the data types written by sshBuffer. The first error is
+// recorded in err, and subsequent reads return zero values.
+type sshReader struct {
+	data []byte
+	err  error
+}
+
+func (r *sshReader) readUint32() uint32 {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.data) < 4 {
+		r.err = errors.New("gpgeez: truncated SSH key")
+		return 0
+	}
+	n := binary.BigEndian.Uint32(r.data)
+	r.data = r.data[4:]
+	return n
+}
+
+func (r *sshReader) readString() []byte {
+	n := r.readUint32()
+	if r.err != nil {
+		return nil
+	}
+	if uint32(len(r.data)) < n {
+		r.err = errors.New("gpgeez: truncated SSH key")
+		return nil
+	}
+	s := r.data[:n]
+	r.data = r.data[n:]
+	return s
+}
+
+func (r *sshReader) readMPInt() *big.Int {
+	return new(big.Int).SetBytes(r.readString())
+}
@@ -0,0 +1,68 @@
+package gpgeez
+
+import (
+	"crypto/ecdsa"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalSSHPrivateKey(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	out, err := key.MarshalSSHPrivateKey()
+	assert.Nil(t, err, "MarshalSSHPrivateKey errored")
+	block, _ := pem.Decode(out)
+	assert.Equal(t, "OPENSSH PRIVATE KEY", block.Type)
+	assert.Equal(t, sshPrivateKeyMagic, string(block.Bytes[:len(sshPrivateKeyMagic)]))
+
+	anonymous := &Key{key.Entity}
+	anonymous.Identities = nil
+	out, err = anonymous.MarshalSSHPrivateKey()
+	assert.Nil(t, err, "MarshalSSHPrivateKey errored without identities")
+	imported, err := ImportSSHPrivateKey(out, "Joe", "", "joe@example.com", &config)
+	assert.Nil(t, err, "ImportSSHPrivateKey errored")
+	assert.Equal(t, key.PrimaryKey.PublicKey, imported.PrimaryKey.PublicKey)
+}
+
+func TestImportSSHPrivateKey(t *testing.T) {
+	for _, curve := range []string{"", "P-384"} {
+		config := Config{Expiry: 365 * 24 * time.Hour, Curve: curve}
+		key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+		assert.Nil(t, err, "CreateKey errored")
+
+		out, err := key.MarshalSSHPrivateKey()
+		assert.Nil(t, err, "MarshalSSHPrivateKey errored")
+
+		config.Curve = ""
+		imported, err := ImportSSHPrivateKey(out, "Joe", "ssh key", "joe@example.com", &config)
+		assert.Nil(t, err, "ImportSSHPrivateKey errored")
+		assert.Equal(t, key.PrimaryKey.PublicKey, imported.PrimaryKey.PublicKey)
+		assert.Equal(t, 1, len(imported.Subkeys))
+	}
+}
+
+func TestImportSSHPrivateKeyInvalidECDSA(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	k := key.PrivateKey.PrivateKey.(*ecdsa.PrivateKey)
+	d := k.D
+
+	for _, bad := range []*big.Int{
+		new(big.Int),
+		k.Curve.Params().N,
+		new(big.Int).Add(d, big.NewInt(1)),
+	} {
+		k.D = bad
+		out, err := key.MarshalSSHPrivateKey()
+		assert.Nil(t, err, "MarshalSSHPrivateKey errored")
+		_, err = ImportSSHPrivateKey(out, "Joe", "ssh key", "joe@example.com", &Config{})
+		assert.NotNil(t, err, "ImportSSHPrivateKey accepted D = %v", bad)
+	}
+}
@@ -0,0 +1,88 @@
+package gpgeez
+
+import (
+	"crypto/ecdsa"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// MinimumKeyStrength is the default threshold for IsWeak: 112 bits, which is
+// what RSA-2048 or three-key TDEA provide.
+const MinimumKeyStrength = 112
+
+// KeyStrength returns the estimated security strength of the primary key, in
+// bits of symmetric-equivalent security, following the comparable strengths
+// table in NIST SP 800-57 Part 1 (section 5.6.1). For example, RSA-2048 is
+// 112 bits, RSA-3072 and P-256 are 128 bits.
+//
+// Keys weaker than 80 bits, or with an unrecognized algorithm, return 0.
+func (key *Key) KeyStrength() int {
+	return keyStrength(key.PrimaryKey)
+}
+
+// IsWeak returns true if the primary key, or any subkey which is neither
+// expired nor revoked, provides fewer than minimumBits of security as computed
+// by KeyStrength. Use MinimumKeyStrength if you don't have a specific policy.
+func (key *Key) IsWeak(minimumBits int) bool {
+	if key.KeyStrength() < minimumBits {
+		return true
+	}
+	now := time.Now()
+	for _, subkey := range key.Subkeys {
+		if isActiveSubkey(subkey, now) && keyStrength(subkey.PublicKey) < minimumBits {
+			return true
+		}
+	}
+	return false
+}
+
+// isActiveSubkey returns true if subkey is neither expired nor revoked at now.
+func isActiveSubkey(subkey openpgp.Subkey, now time.Time) bool {
+	return subkey.Sig.SigType != packet.SigTypeSubkeyRevocation && !subkey.Sig.KeyExpired(now)
+}
+
+func keyStrength(pk *packet.PublicKey) int {
+	switch pk.PubKeyAlgo {
+	case packet.PubKeyAlgoRSA, packet.PubKeyAlgoRSAEncryptOnly, packet.PubKeyAlgoRSASignOnly,
+		packet.PubKeyAlgoDSA, packet.PubKeyAlgoElGamal:
+		// Finite field and integer factorization cryptography.
+		bits, err := pk.BitLength()
+		if err != nil {
+			return 0
+		}
+		switch {
+		case bits >= 15360:
+			return 256
+		case bits >= 7680:
+			return 192
+		case bits >= 3072:
+			return 128
+		case bits >= 2048:
+			return 112
+		case bits >= 1024:
+			return 80
+		}
+	case packet.PubKeyAlgoECDSA:
+		// Elliptic curve cryptography: half the size of the curve's order.
+		pub, ok := pk.PublicKey.(*ecdsa.PublicKey)
+		if !ok {
+			return 0
+		}
+		bits := pub.Curve.Params().BitSize
+		switch {
+		case bits >= 512:
+			return 256
+		case bits >= 384:
+			return 192
+		case bits >= 256:
+			return 128
+		case bits >= 224:
+			return 112
+		case bits >= 160:
+			return 80
+		}
+	}
+	return 0
+}
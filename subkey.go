@@ -0,0 +1,206 @@
+package gpgeez
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// ErrSubkeyNotFound is returned when a key has no subkey matching a lookup.
+var ErrSubkeyNotFound = errors.New("gpgeez: subkey not found")
+
+// ErrNoEncryptionSubkey is returned when a key has no subkey that can be
+// encrypted to.
+var ErrNoEncryptionSubkey = errors.New("gpgeez: no active encryption subkey")
+
+// SubkeyByFingerprint returns the subkey whose fingerprint is fp, in hex.
+// The comparison is case-insensitive and ignores spaces, so the output of
+// gpg --fingerprint can be used.
+func (key *Key) SubkeyByFingerprint(fp string) (*packet.PublicKey, error) {
+	fp = normalizeFingerprint(fp)
+	for _, subkey := range key.Subkeys {
+		if fingerprintString(subkey.PublicKey) == fp {
+			return subkey.PublicKey, nil
+		}
+	}
+	return nil, ErrSubkeyNotFound
+}
+
+// ActiveEncryptionSubkey returns the subkey to encrypt to, which is the one
+// Encrypt uses: among the subkeys flagged for encrypting communications which
+// are neither expired nor revoked, the one with the most recent binding
+// signature.
+func (key *Key) ActiveEncryptionSubkey() (*packet.PublicKey, error) {
+	subkey, ok := key.activeEncryptionSubkey(time.Now())
+	if !ok {
+		return nil, ErrNoEncryptionSubkey
+	}
+	return subkey.PublicKey, nil
+}
+
+// activeEncryptionSubkey follows the rules of the vendored openpgp package,
+// so that it picks the same subkey as openpgp.Encrypt.
+func (key *Key) activeEncryptionSubkey(now time.Time) (openpgp.Subkey, bool) {
+	var r openpgp.Subkey
+	found := false
+	for _, subkey := range key.Subkeys {
+		if !subkey.Sig.FlagsValid || !subkey.Sig.FlagEncryptCommunications ||
+			!subkey.PublicKey.PubKeyAlgo.CanEncrypt() || !isActiveSubkey(subkey, now) {
+			continue
+		}
+		if !found || subkey.Sig.CreationTime.After(r.Sig.CreationTime) {
+			r, found = subkey, true
+		}
+	}
+	return r, found
+}
+
+// AddEncryptionSubkey generates a new encryption subkey of type
+// config.SubkeyType, valid for config.Expiry, and binds it to key. The
+// primary private key must be present and decrypted.
+func (key *Key) AddEncryptionSubkey(config *Config) error {
+	if _, err := checkPrivateKey(key.PrivateKey); err != nil {
+		return err
+	}
+	expiry, err := config.expiry()
+	if err != nil {
+		return err
+	}
+	priv, err := newSubkey(config)
+	if err != nil {
+		return err
+	}
+
+	subkey := newSubkeyBinding(key.PrimaryKey, priv, config)
+	subkey.Sig.KeyLifetimeSecs = keyLifetime(expiry)
+	subkey.Sig.SigLifetimeSecs = config.signatureLifetime()
+	err = subkey.Sig.SignKey(subkey.PublicKey, key.PrivateKey, config.PacketConfig())
+	if err != nil {
+		return err
+	}
+	key.Subkeys = append(key.Subkeys, subkey)
+	return nil
+}
+
+// RevokeSubkey revokes the subkey with the given key id. The subkey's private
+// material, if any, is kept so that old messages can still be decrypted.
+//
+// The vendored openpgp package only keeps one signature per subkey, so the
+// revocation signature replaces the binding signature.
+func (key *Key) RevokeSubkey(keyId uint64, reason RevocationReason, text string, config *Config) error {
+	for i, subkey := range key.Subkeys {
+		if subkey.PublicKey.KeyId != keyId {
+			continue
+		}
+		sig, err := key.subkeyRevocation(subkey, reason, text, config)
+		if err != nil {
+			return err
+		}
+		key.Subkeys[i].Sig = sig
+		return nil
+	}
+	return ErrSubkeyNotFound
+}
+
+// ExtendSubkeyExpiry pushes back the expiration of key.Subkeys[i] by
+// additional, by replacing its binding signature with one made by the primary
+// key. The primary key and the other subkeys are left unchanged. The subkey
+// must be bound with an expiration; revoked subkeys can't be extended. The
+// new lifetime is subject to config.MaxKeyLifetime, like config.Expiry is.
+//
+// The vendored openpgp package can't serialize the primary key binding
+// signature embedded in the binding of signing subkeys, so cross-certified
+// subkeys are rejected rather than left without their cross-certification.
+func (key *Key) ExtendSubkeyExpiry(i int, additional time.Duration, config *Config) error {
+	if key == nil {
+		return ErrNilKey
+	}
+	if i < 0 || i >= len(key.Subkeys) {
+		return fmt.Errorf("gpgeez: no subkey at index %d", i)
+	}
+	if additional <= 0 {
+		return errors.New("gpgeez: the expiry extension must be positive")
+	}
+	priv, err := checkPrivateKey(key.PrivateKey)
+	if err != nil {
+		return err
+	}
+	old := key.Subkeys[i].Sig
+	switch {
+	case old == nil:
+		return errors.New("gpgeez: subkey has no binding signature")
+	case old.SigType == packet.SigTypeSubkeyRevocation:
+		return errors.New("gpgeez: subkey is revoked")
+	case old.KeyLifetimeSecs == nil || *old.KeyLifetimeSecs == 0:
+		return errors.New("gpgeez: subkey doesn't expire")
+	case old.EmbeddedSignature != nil:
+		return errors.New("gpgeez: cannot re-sign a cross-certified subkey")
+	}
+
+	c := *config
+	c.Expiry = time.Duration(*old.KeyLifetimeSecs)*time.Second + additional
+	lifetime, err := c.expiry()
+	if err != nil {
+		return err
+	}
+	sig := *old
+	sig.CreationTime = config.now()
+	sig.Hash = config.hashFor(HashForSubkeyBinding)
+	sig.IssuerKeyId = &priv.KeyId
+	dur := durationSeconds(lifetime)
+	sig.KeyLifetimeSecs = &dur
+	sig.SigLifetimeSecs = config.signatureLifetime()
+	err = sig.SignKey(key.Subkeys[i].PublicKey, priv, config.PacketConfig())
+	if err != nil {
+		return err
+	}
+	key.Subkeys[i].Sig = &sig
+	return nil
+}
+
+// RotateEncryptionSubkey adds a new encryption subkey, and revokes the
+// previous ones with reason RevocationReasonKeyRetired. Their private material is kept so
+// that old messages can still be decrypted. If anything fails, key is left
+// unchanged.
+func (key *Key) RotateEncryptionSubkey(config *Config) error {
+	now := config.now()
+	var old []uint64
+	for _, subkey := range key.Subkeys {
+		if isEncryptionSubkey(subkey) && isActiveSubkey(subkey, now) {
+			old = append(old, subkey.PublicKey.KeyId)
+		}
+	}
+
+	subkeys := append([]openpgp.Subkey(nil), key.Subkeys...)
+	err := key.AddEncryptionSubkey(config)
+	for _, keyId := range old {
+		if err != nil {
+			break
+		}
+		err = key.RevokeSubkey(keyId, RevocationReasonKeyRetired, "", config)
+	}
+	if err != nil {
+		key.Subkeys = subkeys
+	}
+	return err
+}
+
+func (key *Key) subkeyRevocation(subkey openpgp.Subkey, reason RevocationReason, text string, config *Config) (*packet.Signature, error) {
+	priv, err := checkPrivateKey(key.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	sig := newRevocation(packet.SigTypeSubkeyRevocation, priv, reason, text, config)
+	h, err := hashKeyBinding(key.PrimaryKey, subkey.PublicKey, sig)
+	if err != nil {
+		return nil, err
+	}
+	return signWithSubpackets(sig, h, priv, nil, config)
+}
+
+func isEncryptionSubkey(subkey openpgp.Subkey) bool {
+	return subkey.Sig.FlagsValid && (subkey.Sig.FlagEncryptCommunications || subkey.Sig.FlagEncryptStorage)
+}
@@ -0,0 +1,150 @@
+package gpgeez
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+func TestRotateEncryptionSubkey(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	ciphertext, err := key.EncryptToSelf(strings.NewReader("old"), &config)
+	assert.Nil(t, err, "EncryptToSelf errored")
+
+	assert.Nil(t, key.RotateEncryptionSubkey(&config))
+	assert.Equal(t, 2, len(key.Subkeys))
+	assert.Equal(t, packet.SignatureType(packet.SigTypeSubkeyRevocation), key.Subkeys[0].Sig.SigType)
+	assert.Equal(t, uint8(RevocationReasonKeyRetired), *key.Subkeys[0].Sig.RevocationReason)
+	assert.Equal(t, packet.SignatureType(packet.SigTypeSubkeyBinding), key.Subkeys[1].Sig.SigType)
+
+	// The key still parses, and can decrypt old and new messages.
+	key, err = ImportPrivateKey(mustArmorPrivate(t, key))
+	assert.Nil(t, err, "ImportPrivateKey errored")
+	plaintext, err := key.Decrypt(bytes.NewReader(ciphertext), &config)
+	assert.Nil(t, err, "Decrypt errored")
+	assert.Equal(t, "old", string(plaintext))
+
+	ciphertext, err = key.EncryptToSelf(strings.NewReader("new"), &config)
+	assert.Nil(t, err, "EncryptToSelf errored")
+	plaintext, err = key.Decrypt(bytes.NewReader(ciphertext), &config)
+	assert.Nil(t, err, "Decrypt errored")
+	assert.Equal(t, "new", string(plaintext))
+}
+
+func mustArmorPrivate(t *testing.T, key *Key) string {
+	s, err := key.ArmorPrivate(nil)
+	assert.Nil(t, err, "ArmorPrivate errored")
+	return s
+}
+
+func TestRevokeSubkeyLeadingZero(t *testing.T) {
+	c := packet.Config{Rand: NewFakeRand(), Time: FakeTime}
+	config := Config{Config: c, Expiry: 365 * 24 * time.Hour}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	// With this key and text, the RSA signature starts with a zero byte.
+	keyId := key.Subkeys[0].PublicKey.KeyId
+	assert.Nil(t, key.RevokeSubkey(keyId, RevocationReasonKeyRetired, "144", &config))
+	imported, err := ImportPublicKey(mustArmor(t, key))
+	assert.Nil(t, err, "ImportPublicKey errored")
+	assert.Nil(t, imported.PrimaryKey.VerifyKeySignature(imported.Subkeys[0].PublicKey, imported.Subkeys[0].Sig))
+}
+
+func TestSubkeyByFingerprint(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	fp := strings.ToLower(fingerprintString(key.Subkeys[0].PublicKey))
+	subkey, err := key.SubkeyByFingerprint(fp[:20] + " " + fp[20:])
+	assert.Nil(t, err, "SubkeyByFingerprint errored")
+	assert.Equal(t, key.Subkeys[0].PublicKey, subkey)
+
+	_, err = key.SubkeyByFingerprint(key.Fingerprint())
+	assert.Equal(t, ErrSubkeyNotFound, err, "the primary key is not a subkey")
+	assert.Equal(t, ErrSubkeyNotFound, key.RevokeSubkey(0, RevocationReasonKeyRetired, "", &config))
+}
+
+func TestActiveEncryptionSubkey(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	subkey, err := key.ActiveEncryptionSubkey()
+	assert.Nil(t, err, "ActiveEncryptionSubkey errored")
+	assert.Equal(t, key.Subkeys[0].PublicKey, subkey)
+
+	assert.Nil(t, key.RotateEncryptionSubkey(&config))
+	subkey, err = key.ActiveEncryptionSubkey()
+	assert.Nil(t, err, "ActiveEncryptionSubkey errored")
+	assert.Equal(t, key.Subkeys[1].PublicKey, subkey)
+
+	assert.Nil(t, key.RevokeSubkey(subkey.KeyId, RevocationReasonKeyCompromised, "", &config))
+	_, err = key.ActiveEncryptionSubkey()
+	assert.Equal(t, ErrNoEncryptionSubkey, err)
+
+	// Keys which never expire stay active.
+	config.Expiry = 0
+	key, err = CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	_, ok := key.activeEncryptionSubkey(time.Now().Add(100 * 365 * 24 * time.Hour))
+	assert.True(t, ok, "keys without expiry must stay active")
+}
+
+func TestActiveEncryptionSubkeyMatchesEncrypt(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	assert.Nil(t, key.AddEncryptionSubkey(&config))
+
+	// Re-signing the older subkey makes its binding the most recent one,
+	// which is what openpgp.Encrypt goes by.
+	later := time.Now().Add(time.Hour)
+	config.Now = func() time.Time { return later }
+	assert.Nil(t, key.ExtendSubkeyExpiry(0, 24*time.Hour, &config))
+	subkey, ok := key.activeEncryptionSubkey(later)
+	assert.True(t, ok)
+	assert.Equal(t, key.Subkeys[0].PublicKey, subkey.PublicKey)
+
+	ciphertext, err := Encrypt(strings.NewReader("hello world"), []*Key{key}, nil, &config)
+	assert.Nil(t, err, "Encrypt errored")
+	p, err := packet.Read(bytes.NewReader(ciphertext))
+	assert.Nil(t, err, "packet.Read errored")
+	assert.Equal(t, subkey.PublicKey.KeyId, p.(*packet.EncryptedKey).KeyId)
+}
+
+func TestExtendSubkeyExpiry(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	assert.Nil(t, key.AddEncryptionSubkey(&config))
+	primary := *key.primaryIdentity().SelfSignature.KeyLifetimeSecs
+	other := key.Subkeys[0].Sig
+
+	assert.Nil(t, key.ExtendSubkeyExpiry(1, 30*24*time.Hour, &config))
+	assert.Equal(t, uint32((365+30)*24*3600), *key.Subkeys[1].Sig.KeyLifetimeSecs)
+	assert.Equal(t, other, key.Subkeys[0].Sig, "other subkeys must not change")
+	assert.Equal(t, primary, *key.primaryIdentity().SelfSignature.KeyLifetimeSecs)
+
+	imported, err := ImportPublicKey(mustArmor(t, key))
+	assert.Nil(t, err, "ImportPublicKey errored")
+	assert.Equal(t, uint32((365+30)*24*3600), *imported.Subkeys[1].Sig.KeyLifetimeSecs)
+	assert.Nil(t, imported.PrimaryKey.VerifyKeySignature(imported.Subkeys[1].PublicKey, imported.Subkeys[1].Sig))
+
+	assert.NotNil(t, key.ExtendSubkeyExpiry(2, time.Hour, &config))
+	assert.NotNil(t, key.ExtendSubkeyExpiry(0, -time.Hour, &config))
+	limited := config
+	limited.MaxKeyLifetime = 366 * 24 * time.Hour
+	assert.NotNil(t, key.ExtendSubkeyExpiry(0, 30*24*time.Hour, &limited))
+	assert.Equal(t, other, key.Subkeys[0].Sig)
+
+	assert.Nil(t, key.RevokeSubkey(key.Subkeys[0].PublicKey.KeyId, RevocationReasonKeyRetired, "", &config))
+	assert.NotNil(t, key.ExtendSubkeyExpiry(0, time.Hour, &config), "revoked subkeys can't be extended")
+	assert.NotNil(t, imported.ExtendSubkeyExpiry(1, time.Hour, &config), "the primary private key is required")
+}
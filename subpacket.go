@@ -0,0 +1,335 @@
+package gpgeez
+
+import (
+	"bytes"
+	"crypto/dsa"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/binary"
+	"errors"
+	"hash"
+
+	"golang.org/x/crypto/openpgp/packet"
+	"golang.org/x/crypto/openpgp/s2k"
+)
+
+// Signature subpacket types, from https://tools.ietf.org/html/rfc4880#section-5.2.3.1
+// The vendored openpgp package only knows how to write a handful of them.
+const (
+	subpacketCreationTime        = 2
+	subpacketSignatureExpiration = 3
+	subpacketExportableCert      = 4
+	subpacketTrustSignature      = 5
+	subpacketRegularExpression   = 6
+	subpacketKeyExpiration       = 9
+	subpacketPrefSymmetric       = 11
+	subpacketIssuer              = 16
+	subpacketNotationData        = 20
+	subpacketPrefHash            = 21
+	subpacketPrefCompression     = 22
+	subpacketPreferredKeyserver  = 24
+	subpacketPrimaryUserId       = 25
+	subpacketKeyFlags            = 27
+	subpacketReasonForRevocation = 29
+)
+
+// subpacket is a signature subpacket, see https://tools.ietf.org/html/rfc4880#section-5.2.3.1
+type subpacket struct {
+	subpacketType byte
+	critical      bool
+	contents      []byte
+}
+
+// signWithSubpackets is like packet.Signature.Sign, except that the extra
+// subpackets are added to the hashed area. h must contain the hash of the
+// signed data, as computed by hashUserId or hashKeyBinding.
+//
+// The vendored openpgp package has no way to add arbitrary subpackets, so the
+// signature packet is assembled here and parsed back. The returned signature
+// serializes exactly as built, but must not be passed to Sign, SignUserId or
+// SignKey again, as that would drop the extra subpackets.
+func signWithSubpackets(sig *packet.Signature, h hash.Hash, priv *packet.PrivateKey, extra []subpacket, config *Config) (*packet.Signature, error) {
+	if priv == nil || priv.Encrypted {
+		return nil, errors.New("gpgeez: signing key must be present and decrypted")
+	}
+	hashId, ok := s2k.HashToHashId(sig.Hash)
+	if !ok {
+		return nil, errors.New("gpgeez: unsupported hash function")
+	}
+
+	hashed := new(bytes.Buffer)
+	for _, sp := range append(standardSubpackets(sig), extra...) {
+		serializeSubpacket(hashed, sp)
+	}
+	if hashed.Len() > 0xffff {
+		return nil, errors.New("gpgeez: signature subpackets too long")
+	}
+
+	// See https://tools.ietf.org/html/rfc4880#section-5.2.4
+	prefix := []byte{4, byte(sig.SigType), byte(priv.PubKeyAlgo), hashId, byte(hashed.Len() >> 8), byte(hashed.Len())}
+	prefix = append(prefix, hashed.Bytes()...)
+	h.Write(prefix)
+	trailer := []byte{4, 0xff, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(trailer[2:], uint32(len(prefix)))
+	h.Write(trailer)
+	digest := h.Sum(nil)
+
+	var mpis bytes.Buffer
+	switch k := priv.PrivateKey.(type) {
+	case *rsa.PrivateKey:
+		s, err := rsa.SignPKCS1v15(config.Random(), k, sig.Hash, digest)
+		if err != nil {
+			return nil, err
+		}
+		// Like the vendored package, keep the leading zeros: crypto/rsa only
+		// verifies signatures as long as the modulus.
+		writeMPI(&mpis, 8*len(s), s)
+	case *dsa.PrivateKey:
+		// Truncate the digest to the subgroup size, see FIPS 186-3 section 4.6.
+		if n := (k.Q.BitLen() + 7) / 8; len(digest) > n {
+			digest = digest[:n]
+		}
+		r, s, err := dsa.Sign(config.Random(), k, digest)
+		if err != nil {
+			return nil, err
+		}
+		writeMPI(&mpis, r.BitLen(), r.Bytes())
+		writeMPI(&mpis, s.BitLen(), s.Bytes())
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(config.Random(), k, digest)
+		if err != nil {
+			return nil, err
+		}
+		writeMPI(&mpis, r.BitLen(), r.Bytes())
+		writeMPI(&mpis, s.BitLen(), s.Bytes())
+	default:
+		return nil, errors.New("gpgeez: unsupported signing key algorithm")
+	}
+
+	body := new(bytes.Buffer)
+	body.Write(prefix)
+	body.Write([]byte{0, 0}) // no unhashed subpackets
+	body.Write(digest[:2])
+	body.Write(mpis.Bytes())
+
+	buf := new(bytes.Buffer)
+	serializePacketHeader(buf, 2, body.Len())
+	buf.Write(body.Bytes())
+	p, err := packet.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return p.(*packet.Signature), nil
+}
+
+func writeMPI(w *bytes.Buffer, bitLength int, b []byte) {
+	binary.Write(w, binary.BigEndian, uint16(bitLength))
+	w.Write(b)
+}
+
+// standardSubpackets returns the hashed subpackets that the vendored openpgp
+// package would write for sig, in the same order.
+func standardSubpackets(sig *packet.Signature) []subpacket {
+	var r []subpacket
+	creationTime := make([]byte, 4)
+	binary.BigEndian.PutUint32(creationTime, uint32(sig.CreationTime.Unix()))
+	r = append(r, subpacket{subpacketCreationTime, false, creationTime})
+
+	if sig.IssuerKeyId != nil {
+		keyId := make([]byte, 8)
+		binary.BigEndian.PutUint64(keyId, *sig.IssuerKeyId)
+		r = append(r, subpacket{subpacketIssuer, false, keyId})
+	}
+	if sig.SigLifetimeSecs != nil && *sig.SigLifetimeSecs != 0 {
+		r = append(r, subpacket{subpacketSignatureExpiration, true, uint32Bytes(*sig.SigLifetimeSecs)})
+	}
+	if sig.FlagsValid {
+		var flags byte
+		if sig.FlagCertify {
+			flags |= packet.KeyFlagCertify
+		}
+		if sig.FlagSign {
+			flags |= packet.KeyFlagSign
+		}
+		if sig.FlagEncryptCommunications {
+			flags |= packet.KeyFlagEncryptCommunications
+		}
+		if sig.FlagEncryptStorage {
+			flags |= packet.KeyFlagEncryptStorage
+		}
+		r = append(r, subpacket{subpacketKeyFlags, false, []byte{flags}})
+	}
+	if sig.KeyLifetimeSecs != nil && *sig.KeyLifetimeSecs != 0 {
+		r = append(r, subpacket{subpacketKeyExpiration, true, uint32Bytes(*sig.KeyLifetimeSecs)})
+	}
+	if sig.IsPrimaryId != nil && *sig.IsPrimaryId {
+		r = append(r, subpacket{subpacketPrimaryUserId, false, []byte{1}})
+	}
+	if len(sig.PreferredSymmetric) > 0 {
+		r = append(r, subpacket{subpacketPrefSymmetric, false, sig.PreferredSymmetric})
+	}
+	if len(sig.PreferredHash) > 0 {
+		r = append(r, subpacket{subpacketPrefHash, false, sig.PreferredHash})
+	}
+	if len(sig.PreferredCompression) > 0 {
+		r = append(r, subpacket{subpacketPrefCompression, false, sig.PreferredCompression})
+	}
+	if sig.RevocationReason != nil {
+		contents := append([]byte{*sig.RevocationReason}, sig.RevocationReasonText...)
+		r = append(r, subpacket{subpacketReasonForRevocation, false, contents})
+	}
+	return r
+}
+
+func uint32Bytes(n uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, n)
+	return b
+}
+
+func serializeSubpacket(w *bytes.Buffer, sp subpacket) {
+	// The length includes the type octet.
+	writeLength(w, len(sp.contents)+1)
+	t := sp.subpacketType
+	if sp.critical {
+		t |= 0x80
+	}
+	w.WriteByte(t)
+	w.Write(sp.contents)
+}
+
+// serializePacketHeader writes a new format packet header, see
+// https://tools.ietf.org/html/rfc4880#section-4.2
+func serializePacketHeader(w *bytes.Buffer, tag byte, length int) {
+	w.WriteByte(0xc0 | tag)
+	writeLength(w, length)
+}
+
+// writeLength writes a new format length, which is the same for packets and
+// signature subpackets.
+func writeLength(w *bytes.Buffer, length int) {
+	switch {
+	case length < 192:
+		w.WriteByte(byte(length))
+	case length < 8384:
+		length -= 192
+		w.Write([]byte{byte(length>>8) + 192, byte(length)})
+	default:
+		w.WriteByte(255)
+		binary.Write(w, binary.BigEndian, uint32(length))
+	}
+}
+
+// hashedSubpackets returns the subpackets in the hashed area of sig. The
+// vendored openpgp package drops the subpackets it doesn't understand once
+// parsed, so they are recovered from the serialized signature.
+func hashedSubpackets(sig *packet.Signature) ([]subpacket, error) {
+	buf := new(bytes.Buffer)
+	err := sig.Serialize(buf)
+	if err != nil {
+		return nil, err
+	}
+	body, err := packetBody(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	if len(body) < 6 || body[0] != 4 {
+		// Version 3 signatures don't have subpackets.
+		return nil, nil
+	}
+	length := int(body[4])<<8 | int(body[5])
+	if len(body) < 6+length {
+		return nil, errors.New("gpgeez: truncated signature subpackets")
+	}
+	return parseSubpackets(body[6 : 6+length])
+}
+
+// parseSubpackets parses a subpacket area, as written by serializeSubpacket.
+func parseSubpackets(data []byte) ([]subpacket, error) {
+	var r []subpacket
+	for len(data) > 0 {
+		var length int
+		switch {
+		case data[0] < 192:
+			length, data = int(data[0]), data[1:]
+		case data[0] < 255 && len(data) >= 2:
+			length, data = (int(data[0])-192)<<8+int(data[1])+192, data[2:]
+		case data[0] == 255 && len(data) >= 5:
+			length, data = int(binary.BigEndian.Uint32(data[1:])), data[5:]
+		default:
+			return nil, errors.New("gpgeez: truncated signature subpackets")
+		}
+		if length < 1 || length > len(data) {
+			return nil, errors.New("gpgeez: invalid signature subpacket length")
+		}
+		r = append(r, subpacket{data[0] & 0x7f, data[0]&0x80 != 0, data[1:length]})
+		data = data[length:]
+	}
+	return r, nil
+}
+
+// hashKey writes the public key pk to h, the way it is hashed in key and user
+// id signatures. See https://tools.ietf.org/html/rfc4880#section-5.2.4
+func hashKey(h hash.Hash, pk *packet.PublicKey) error {
+	buf := new(bytes.Buffer)
+	err := pk.Serialize(buf)
+	if err != nil {
+		return err
+	}
+	body, err := packetBody(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	h.Write([]byte{0x99, byte(len(body) >> 8), byte(len(body))})
+	h.Write(body)
+	return nil
+}
+
+// packetBody strips the new format header from a serialized packet.
+func packetBody(p []byte) ([]byte, error) {
+	if len(p) < 2 || p[0]&0xc0 != 0xc0 {
+		return nil, errors.New("gpgeez: unexpected packet format")
+	}
+	switch {
+	case p[1] < 192:
+		return p[2:], nil
+	case p[1] < 224 && len(p) >= 3:
+		return p[3:], nil
+	case p[1] == 255 && len(p) >= 6:
+		return p[6:], nil
+	}
+	return nil, errors.New("gpgeez: unexpected packet length")
+}
+
+// hashUserId returns the hash over which a certification of id on pk is made.
+func hashUserId(id string, pk *packet.PublicKey, sig *packet.Signature) (hash.Hash, error) {
+	if !sig.Hash.Available() {
+		return nil, errors.New("gpgeez: hash function is not available")
+	}
+	h := sig.Hash.New()
+	err := hashKey(h, pk)
+	if err != nil {
+		return nil, err
+	}
+	h.Write([]byte{0xb4, byte(len(id) >> 24), byte(len(id) >> 16), byte(len(id) >> 8), byte(len(id))})
+	h.Write([]byte(id))
+	return h, nil
+}
+
+// hashKeyBinding returns the hash over which a binding (or revocation) of
+// subkey to primary is made.
+func hashKeyBinding(primary, subkey *packet.PublicKey, sig *packet.Signature) (hash.Hash, error) {
+	if !sig.Hash.Available() {
+		return nil, errors.New("gpgeez: hash function is not available")
+	}
+	h := sig.Hash.New()
+	err := hashKey(h, primary)
+	if err != nil {
+		return nil, err
+	}
+	err = hashKey(h, subkey)
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
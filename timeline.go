@@ -0,0 +1,80 @@
+package gpgeez
+
+import (
+	"sort"
+	"time"
+
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// sigTypeCertificationRevocation is missing from the vendored openpgp package,
+// see https://tools.ietf.org/html/rfc4880#section-5.2.1
+const sigTypeCertificationRevocation packet.SignatureType = 0x30
+
+// KeyEvent is an entry of a key's Timeline.
+type KeyEvent struct {
+	Time time.Time
+	// Type is one of "key_created", "key_revoked", "uid_added",
+	// "uid_certified", "uid_revoked", "expiry_updated", "subkey_added" or
+	// "subkey_revoked".
+	Type string
+	// Detail is the user id or key id the event is about.
+	Detail string
+}
+
+// Timeline returns the events in the key's history, in chronological order.
+// It is derived entirely from the key's own packets, so it only covers what
+// the key still carries: for instance the vendored openpgp package keeps a
+// single signature per subkey, so a revoked subkey's binding time is lost
+// and its creation time is used instead.
+func (key *Key) Timeline() []KeyEvent {
+	keyId := key.PrimaryKey.KeyIdString()
+	events := []KeyEvent{{key.PrimaryKey.CreationTime, "key_created", keyId}}
+	for _, sig := range key.Revocations {
+		events = append(events, KeyEvent{sig.CreationTime, "key_revoked", keyId})
+	}
+
+	for _, id := range key.Identities {
+		var selfSigs []*packet.Signature
+		if id.SelfSignature != nil {
+			selfSigs = append(selfSigs, id.SelfSignature)
+		}
+		for _, sig := range id.Signatures {
+			switch {
+			case sig.SigType == sigTypeCertificationRevocation:
+				events = append(events, KeyEvent{sig.CreationTime, "uid_revoked", id.Name})
+			case !isSelfSignature(sig, key.PrimaryKey):
+				events = append(events, KeyEvent{sig.CreationTime, "uid_certified", id.Name})
+			default:
+				selfSigs = append(selfSigs, sig)
+			}
+		}
+		sort.Stable(signaturesByCreationTime(selfSigs))
+		for i, sig := range selfSigs {
+			if i == 0 {
+				events = append(events, KeyEvent{sig.CreationTime, "uid_added", id.Name})
+			} else if sig.KeyLifetimeSecs != nil {
+				events = append(events, KeyEvent{sig.CreationTime, "expiry_updated", id.Name})
+			}
+		}
+	}
+
+	for _, subkey := range key.Subkeys {
+		subkeyId := subkey.PublicKey.KeyIdString()
+		if subkey.Sig.SigType == packet.SigTypeSubkeyRevocation {
+			events = append(events, KeyEvent{subkey.PublicKey.CreationTime, "subkey_added", subkeyId})
+			events = append(events, KeyEvent{subkey.Sig.CreationTime, "subkey_revoked", subkeyId})
+		} else {
+			events = append(events, KeyEvent{subkey.Sig.CreationTime, "subkey_added", subkeyId})
+		}
+	}
+
+	sort.Stable(eventsByTime(events))
+	return events
+}
+
+type eventsByTime []KeyEvent
+
+func (s eventsByTime) Len() int           { return len(s) }
+func (s eventsByTime) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s eventsByTime) Less(i, j int) bool { return s[i].Time.Before(s[j].Time) }
@@ -0,0 +1,25 @@
+package gpgeez
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeline(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour}
+	now := time.Now().Truncate(time.Second)
+	config.Now = func() time.Time { return now }
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	now = now.Add(time.Hour)
+	assert.Nil(t, key.RotateEncryptionSubkey(&config))
+
+	var types []string
+	for _, event := range key.Timeline() {
+		types = append(types, event.Type)
+	}
+	assert.Equal(t, []string{"key_created", "uid_added", "subkey_added", "subkey_revoked", "subkey_added"}, types)
+}
@@ -0,0 +1,54 @@
+package gpgeez
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// sigTypeTimestamp is missing from the vendored openpgp package, see
+// https://tools.ietf.org/html/rfc4880#section-5.2.1
+const sigTypeTimestamp packet.SignatureType = 0x40
+
+// TimestampSignature returns a timestamp signature (type 0x40) over data, as
+// a binary signature packet. Notarization services use them to prove that
+// data existed at the signature's creation time, config.Now.
+func (key *Key) TimestampSignature(data []byte, config *Config) ([]byte, error) {
+	priv, err := key.signingKey(config.now())
+	if err != nil {
+		return nil, err
+	}
+	return detachSign(priv, sigTypeTimestamp, config.Hash(), bytes.NewReader(data), config)
+}
+
+// VerifyTimestamp checks that sig is a timestamp signature over data made by
+// key, and returns the time it attests to.
+func (key *Key) VerifyTimestamp(data, sig []byte) (time.Time, error) {
+	s, err := parseSignature(sig)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if s.SigType != sigTypeTimestamp {
+		return time.Time{}, errors.New("gpgeez: not a timestamp signature")
+	}
+	if s.IssuerKeyId == nil {
+		return time.Time{}, errors.New("gpgeez: signature has no issuer")
+	}
+	keys := openpgp.EntityList{&key.Entity}.KeysById(*s.IssuerKeyId)
+	if len(keys) == 0 {
+		return time.Time{}, errors.New("gpgeez: signature was not made by this key")
+	}
+	if !s.Hash.Available() {
+		return time.Time{}, errors.New("gpgeez: hash function is not available")
+	}
+	h := s.Hash.New()
+	h.Write(data)
+	err = keys[0].PublicKey.VerifySignature(h, s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return s.CreationTime, nil
+}
@@ -0,0 +1,31 @@
+package gpgeez
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimestampSignature(t *testing.T) {
+	now := time.Now().Add(-time.Hour).Truncate(time.Second)
+	config := Config{Expiry: 365 * 24 * time.Hour, Now: func() time.Time { return now }}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	sig, err := key.TimestampSignature([]byte("document"), &config)
+	assert.Nil(t, err, "TimestampSignature errored")
+	ts, err := key.VerifyTimestamp([]byte("document"), sig)
+	assert.Nil(t, err, "VerifyTimestamp errored")
+	assert.Equal(t, now.Unix(), ts.Unix())
+
+	_, err = key.VerifyTimestamp([]byte("other document"), sig)
+	assert.NotNil(t, err)
+
+	// Regular signatures are not timestamps.
+	sig, err = key.Sign(strings.NewReader("document"), &config)
+	assert.Nil(t, err, "Sign errored")
+	_, err = key.VerifyTimestamp([]byte("document"), sig)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,174 @@
+package gpgeez
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// SaveTOML writes the public part of the keys in kr to the file path, in a
+// human-editable TOML format: a [[keys]] table per key, with the fingerprint
+// and the armored key.
+//
+//	[[keys]]
+//	fingerprint = "40F7EF92A33E2ED508588827529F1947C201A313"
+//	armored = '''
+//	-----BEGIN PGP PUBLIC KEY BLOCK-----
+//	...
+//	'''
+func (kr *KeyRing) SaveTOML(path string) error {
+	buf := new(bytes.Buffer)
+	for i, key := range kr.Keys() {
+		armored, err := key.Armor()
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(buf, "[[keys]]\nfingerprint = %q\narmored = '''\n%s\n'''\n", key.Fingerprint(), armored)
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// LoadKeyRingTOML reads a keyring written by SaveTOML. The fingerprint of each
+// entry must match its armored key, so that mistakes made while editing the
+// file are caught.
+//
+// Since no TOML parser is vendored, only the subset of TOML used by SaveTOML
+// is supported: comments, [[keys]] tables, and single-line or multi-line
+// strings without escape sequences in the latter.
+func LoadKeyRingTOML(path string) (*KeyRing, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := parseKeyRingTOML(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("gpgeez: reading %s: %w", path, err)
+	}
+	kr := NewKeyRing()
+	for i, entry := range entries {
+		key, err := ImportPublicKey(entry["armored"])
+		if err != nil {
+			return nil, fmt.Errorf("gpgeez: reading %s: key %d: %w", path, i, err)
+		}
+		if key.Fingerprint() != normalizeFingerprint(entry["fingerprint"]) {
+			return nil, fmt.Errorf("gpgeez: reading %s: key %d has fingerprint %s, not %s", path, i, key.Fingerprint(), entry["fingerprint"])
+		}
+		kr.Add(key)
+	}
+	return kr, nil
+}
+
+// parseKeyRingTOML parses the [[keys]] tables of a document written by
+// SaveTOML.
+func parseKeyRingTOML(data string) ([]map[string]string, error) {
+	var r []map[string]string
+	lines := strings.Split(data, "\n")
+	for i := 0; i < len(lines); i++ {
+		n := i + 1
+		line := strings.TrimSpace(lines[i])
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			if stripComment(line) != "[[keys]]" {
+				return nil, fmt.Errorf("line %d: unexpected table %s", n, line)
+			}
+			r = append(r, make(map[string]string))
+			continue
+		}
+
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("line %d: expected key = value", n)
+		}
+		name := strings.TrimSpace(line[:eq])
+		value := strings.TrimSpace(line[eq+1:])
+		if name != "fingerprint" && name != "armored" {
+			return nil, fmt.Errorf("line %d: unknown key %q", n, name)
+		}
+		if len(r) == 0 {
+			return nil, fmt.Errorf("line %d: %s is outside of [[keys]]", n, name)
+		}
+		if _, ok := r[len(r)-1][name]; ok {
+			return nil, fmt.Errorf("line %d: duplicate key %q", n, name)
+		}
+
+		var s, rest string
+		if strings.HasPrefix(value, "'''") || strings.HasPrefix(value, `"""`) {
+			// Multi-line string. A newline right after the delimiter is
+			// trimmed.
+			delim := value[:3]
+			text := value[3:]
+			for !strings.Contains(text, delim) {
+				i++
+				if i == len(lines) {
+					return nil, fmt.Errorf("line %d: unterminated string", n)
+				}
+				text += "\n" + lines[i]
+			}
+			end := strings.Index(text, delim)
+			s, rest = strings.TrimPrefix(text[:end], "\n"), text[end+3:]
+			if delim == `"""` && strings.Contains(s, `\`) {
+				return nil, fmt.Errorf("line %d: escape sequences in multi-line strings are not supported", n)
+			}
+		} else {
+			var err error
+			s, rest, err = parseTOMLString(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+		}
+		if stripComment(rest) != "" {
+			return nil, fmt.Errorf("line %d: unexpected %q after value", i+1, rest)
+		}
+		r[len(r)-1][name] = s
+	}
+
+	for i, entry := range r {
+		if entry["fingerprint"] == "" || entry["armored"] == "" {
+			return nil, fmt.Errorf("key %d: fingerprint and armored are required", i)
+		}
+	}
+	return r, nil
+}
+
+// parseTOMLString parses the single-line basic or literal string at the start
+// of value, and returns it along with the rest of value.
+func parseTOMLString(value string) (string, string, error) {
+	if value == "" {
+		return "", "", fmt.Errorf("missing value")
+	}
+	switch value[0] {
+	case '\'':
+		end := strings.Index(value[1:], "'")
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated string")
+		}
+		return value[1 : end+1], value[end+2:], nil
+	case '"':
+		for end := 1; end < len(value); end++ {
+			switch value[end] {
+			case '\\':
+				end++
+			case '"':
+				s, err := strconv.Unquote(value[:end+1])
+				return s, value[end+1:], err
+			}
+		}
+		return "", "", fmt.Errorf("unterminated string")
+	}
+	return "", "", fmt.Errorf("values must be strings")
+}
+
+// stripComment removes a trailing comment and spaces from s.
+func stripComment(s string) string {
+	if i := strings.Index(s, "#"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,56 @@
+package gpgeez
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyRingTOML(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gpgeez")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "keyring.toml")
+
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	kr := NewKeyRing()
+	for _, name := range []string{"Joe", "Jane"} {
+		key, err := CreateKey(name, "test key", name+"@example.com", &config)
+		assert.Nil(t, err, "CreateKey errored")
+		kr.Add(key)
+	}
+	assert.Nil(t, kr.SaveTOML(path))
+	loaded, err := LoadKeyRingTOML(path)
+	assert.Nil(t, err, "LoadKeyRingTOML errored")
+	assert.Equal(t, kr.Len(), loaded.Len())
+	for i, key := range kr.Keys() {
+		assert.Equal(t, key.Keyring(), loaded.Keys()[i].Keyring())
+	}
+
+	// Hand edits: comments, basic strings and spaced fingerprints.
+	armored, err := kr.Keys()[0].Armor()
+	assert.Nil(t, err)
+	fp := kr.Keys()[0].Fingerprint()
+	edited := "# Team keys\n\n[[keys]] # Joe\nfingerprint = \"" + fp[:20] + " " + fp[20:] + "\" # primary\narmored = \"\"\"\n" + armored + "\"\"\"\n"
+	assert.Nil(t, ioutil.WriteFile(path, []byte(edited), 0644))
+	loaded, err = LoadKeyRingTOML(path)
+	assert.Nil(t, err, "LoadKeyRingTOML errored")
+	assert.Equal(t, 1, loaded.Len())
+
+	for _, bad := range []string{
+		strings.Replace(edited, fp[:20], strings.Repeat("0", 20), 1),
+		strings.Replace(edited, "fingerprint", "fingerprnt", 1),
+		strings.Replace(edited, "[[keys]]", "[keys]", 1),
+		strings.TrimSuffix(edited, "\"\"\"\n"),
+		"fingerprint = \"" + fp + "\"\n",
+	} {
+		assert.Nil(t, ioutil.WriteFile(path, []byte(bad), 0644))
+		_, err = LoadKeyRingTOML(path)
+		assert.NotNil(t, err, bad)
+	}
+}
@@ -0,0 +1,38 @@
+package gpgeez
+
+import (
+	"sync"
+)
+
+// TrustLevel is the owner trust of a key: how much the key's owner is trusted
+// to certify other keys. The levels are the choices of the gpg --edit-key
+// trust menu, in the same order, but gpg numbers them from 1 to 5 while the
+// values here start at 0, so that the zero value is TrustUnknown.
+type TrustLevel uint8
+
+const (
+	TrustUnknown TrustLevel = iota
+	TrustNone
+	TrustMarginal
+	TrustFull
+	TrustUltimate
+)
+
+// trustStore maps fingerprints to trust levels. It is shared by the whole
+// process, and is not persisted.
+var trustStore sync.Map
+
+// SetTrust records the owner trust of key. The trust is kept in memory, keyed
+// by fingerprint, so it applies to every copy of the key.
+func (key *Key) SetTrust(level TrustLevel) {
+	trustStore.Store(key.Fingerprint(), level)
+}
+
+// Trust returns the owner trust set with SetTrust, or TrustUnknown.
+func (key *Key) Trust() TrustLevel {
+	level, ok := trustStore.Load(key.Fingerprint())
+	if !ok {
+		return TrustUnknown
+	}
+	return level.(TrustLevel)
+}
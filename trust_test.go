@@ -0,0 +1,25 @@
+package gpgeez
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrust(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	assert.Equal(t, TrustUnknown, key.Trust())
+
+	key.SetTrust(TrustMarginal)
+	assert.Equal(t, TrustMarginal, key.Trust())
+
+	// The trust follows the fingerprint, not the *Key.
+	public, err := ImportPublicKey(mustArmor(t, key))
+	assert.Nil(t, err, "ImportPublicKey errored")
+	assert.Equal(t, TrustMarginal, public.Trust())
+	public.SetTrust(TrustFull)
+	assert.Equal(t, TrustFull, key.Trust())
+}
@@ -0,0 +1,215 @@
+package gpgeez
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// ValidateKey checks the self-signatures of an imported key, and that the key
+// doesn't rely on weak algorithms: MD5 or SHA-1 self-signatures, or keys
+// weaker than MinimumKeyStrength such as RSA-1024. Weak algorithms are
+// accepted, with a logged warning, if config.AllowWeakAlgorithms is set.
+func (key *Key) ValidateKey(config *Config) error {
+	err := config.checkKeyStrength(key.PrimaryKey)
+	if err != nil {
+		return err
+	}
+	for _, id := range key.Identities {
+		if id.SelfSignature == nil {
+			return errors.New("gpgeez: identity " + id.Name + " is not self-signed")
+		}
+		err = config.checkHash(id.SelfSignature.Hash)
+		if err != nil {
+			return err
+		}
+		err = key.PrimaryKey.VerifyUserIdSignature(id.UserId.Id, key.PrimaryKey, id.SelfSignature)
+		if err != nil {
+			return err
+		}
+	}
+	now := config.now()
+	for _, subkey := range key.Subkeys {
+		err = config.checkHash(subkey.Sig.Hash)
+		if err != nil {
+			return err
+		}
+		err = key.PrimaryKey.VerifyKeySignature(subkey.PublicKey, subkey.Sig)
+		if err != nil {
+			return err
+		}
+		if isActiveSubkey(subkey, now) {
+			err = config.checkKeyStrength(subkey.PublicKey)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkHash rejects MD5 and SHA-1, unless config.AllowWeakAlgorithms is set.
+func (config *Config) checkHash(h crypto.Hash) error {
+	if h != crypto.MD5 && h != crypto.SHA1 {
+		return nil
+	}
+	return config.weakAlgorithm(fmt.Sprintf("signature uses weak hash %v", h))
+}
+
+// checkKeyStrength rejects keys weaker than MinimumKeyStrength, unless
+// config.AllowWeakAlgorithms is set.
+func (config *Config) checkKeyStrength(pk *packet.PublicKey) error {
+	if keyStrength(pk) >= MinimumKeyStrength {
+		return nil
+	}
+	return config.weakAlgorithm(fmt.Sprintf("key %s is weak", pk.KeyIdString()))
+}
+
+func (config *Config) weakAlgorithm(msg string) error {
+	if !config.AllowWeakAlgorithms {
+		return errors.New("gpgeez: " + msg)
+	}
+	config.log(slog.LevelWarn, "accepting weak algorithm because AllowWeakAlgorithms is set", "reason", msg)
+	return nil
+}
+
+// SelfSignatureHash returns the hash function of the most recent
+// self-signature of the primary identity, e.g. to check that a key was not
+// certified with SHA-1.
+func (key *Key) SelfSignatureHash() (crypto.Hash, error) {
+	if key == nil {
+		return 0, ErrNilKey
+	}
+	sig := key.latestSelfSignature()
+	if sig == nil {
+		return 0, errors.New("gpgeez: key has no self-signature")
+	}
+	return sig.Hash, nil
+}
+
+// SubkeySelfSignatureHash returns the hash function of the binding (or
+// revocation) signature of key.Subkeys[i].
+func (key *Key) SubkeySelfSignatureHash(i int) (crypto.Hash, error) {
+	if key == nil {
+		return 0, ErrNilKey
+	}
+	if i < 0 || i >= len(key.Subkeys) {
+		return 0, fmt.Errorf("gpgeez: no subkey at index %d", i)
+	}
+	if key.Subkeys[i].Sig == nil {
+		return 0, errors.New("gpgeez: subkey has no self-signature")
+	}
+	return key.Subkeys[i].Sig.Hash, nil
+}
+
+// latestSelfSignature returns the most recent valid self-signature of the
+// primary identity. The openpgp package keeps the first one it finds in
+// SelfSignature, and the later ones in Signatures.
+func (key *Key) latestSelfSignature() *packet.Signature {
+	id := key.primaryIdentity()
+	if id == nil {
+		return nil
+	}
+	latest := id.SelfSignature
+	for _, sig := range id.Signatures {
+		if !isCertification(sig.SigType) || !isSelfSignature(sig, key.PrimaryKey) {
+			continue
+		}
+		if latest != nil && !sig.CreationTime.After(latest.CreationTime) {
+			continue
+		}
+		if key.PrimaryKey.VerifyUserIdSignature(id.UserId.Id, key.PrimaryKey, sig) == nil {
+			latest = sig
+		}
+	}
+	return latest
+}
+
+// Packet tags of the packets which make up a key, see
+// https://tools.ietf.org/html/rfc4880#section-4.3
+const (
+	tagSignature     = 2
+	tagPrivateKey    = 5
+	tagPublicKey     = 6
+	tagPrivateSubkey = 7
+	tagTrust         = 12
+	tagUserId        = 13
+	tagPublicSubkey  = 14
+	tagUserAttribute = 17
+)
+
+// CheckPacketOrder serializes the public part of key and checks that its
+// packets are in the order required by
+// https://tools.ietf.org/html/rfc4880#section-11.1: the primary key, its
+// direct signatures, the user ids and user attributes followed by their
+// certifications, then the subkeys each followed by a binding signature. The
+// error identifies the first packet out of order.
+func (key *Key) CheckPacketOrder() error {
+	buf := new(bytes.Buffer)
+	err := key.Serialize(buf)
+	if err != nil {
+		return err
+	}
+	return checkPacketOrder(buf)
+}
+
+// checkPacketOrder checks the order of the packets of a single key read from
+// r, see CheckPacketOrder. Trust packets, which are only found in keyrings,
+// are ignored.
+func checkPacketOrder(r io.Reader) error {
+	const (
+		start = iota
+		primary
+		uids
+		subkeys
+	)
+	state := start
+	unsigned := false
+	or := packet.NewOpaqueReader(r)
+	for i := 0; ; i++ {
+		p, err := or.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		outOfOrder := false
+		switch p.Tag {
+		case tagPublicKey, tagPrivateKey:
+			outOfOrder = state != start
+			state = primary
+		case tagSignature:
+			outOfOrder = state == start
+			unsigned = false
+		case tagUserId, tagUserAttribute:
+			outOfOrder = state != primary && state != uids
+			state = uids
+		case tagPublicSubkey, tagPrivateSubkey:
+			outOfOrder = state != uids && state != subkeys || unsigned
+			state = subkeys
+			unsigned = true
+		case tagTrust:
+			outOfOrder = state == start
+		default:
+			return fmt.Errorf("gpgeez: packet %d has unexpected type %d", i, p.Tag)
+		}
+		if outOfOrder {
+			return fmt.Errorf("gpgeez: packet %d of type %d is out of order", i, p.Tag)
+		}
+	}
+	switch {
+	case state == start:
+		return errors.New("gpgeez: no primary key")
+	case state == primary:
+		return errors.New("gpgeez: no user id")
+	case unsigned:
+		return errors.New("gpgeez: last subkey has no binding signature")
+	}
+	return nil
+}
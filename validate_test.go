@@ -0,0 +1,103 @@
+package gpgeez
+
+import (
+	"bytes"
+	"crypto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+func TestValidateKey(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	assert.Nil(t, key.ValidateKey(&config))
+
+	config.RSABits = 1024
+	key, err = CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	assert.NotNil(t, key.ValidateKey(&config), "RSA-1024 should be rejected")
+	config.AllowWeakAlgorithms = true
+	assert.Nil(t, key.ValidateKey(&config))
+}
+
+func TestVerifyWeakHash(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour}
+	config.DefaultHash = crypto.SHA1
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	sig, err := key.Sign(strings.NewReader("hello"), &config)
+	assert.Nil(t, err, "Sign errored")
+	assert.NotNil(t, key.Verify(strings.NewReader("hello"), sig, &config), "SHA-1 should be rejected")
+	config.AllowWeakAlgorithms = true
+	assert.Nil(t, key.Verify(strings.NewReader("hello"), sig, &config))
+}
+
+func TestSelfSignatureHash(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256", DefaultHash: crypto.SHA512}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	h, err := key.SelfSignatureHash()
+	assert.Nil(t, err, "SelfSignatureHash errored")
+	assert.Equal(t, crypto.SHA512, h)
+	h, err = key.SubkeySelfSignatureHash(0)
+	assert.Nil(t, err, "SubkeySelfSignatureHash errored")
+	assert.Equal(t, crypto.SHA512, h)
+	_, err = key.SubkeySelfSignatureHash(1)
+	assert.NotNil(t, err)
+
+	// A newer self-signature, as added when the expiry is updated.
+	later := config
+	later.DefaultHash = crypto.SHA384
+	later.Now = func() time.Time { return time.Now().Add(time.Hour) }
+	id := key.primaryIdentity()
+	sig, err := key.certifyUID(key, id, packet.SigTypePositiveCert, nil, &later)
+	assert.Nil(t, err, "certifyUID errored")
+	id.Signatures = append(id.Signatures, sig)
+	h, err = key.SelfSignatureHash()
+	assert.Nil(t, err, "SelfSignatureHash errored")
+	assert.Equal(t, crypto.SHA384, h)
+}
+
+func TestCheckPacketOrder(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	assert.Nil(t, key.CheckPacketOrder())
+
+	// Primary key, uid, certification, subkey, binding.
+	var packets []*packet.OpaquePacket
+	or := packet.NewOpaqueReader(bytes.NewReader(key.Keyring()))
+	for {
+		p, err := or.Next()
+		if err != nil {
+			break
+		}
+		packets = append(packets, p)
+	}
+	assert.Equal(t, 5, len(packets))
+	serialize := func(order ...int) *bytes.Buffer {
+		buf := new(bytes.Buffer)
+		for _, i := range order {
+			assert.Nil(t, packets[i].Serialize(buf))
+		}
+		return buf
+	}
+	assert.Nil(t, checkPacketOrder(serialize(0, 1, 2, 3, 4)))
+
+	err = checkPacketOrder(serialize(0, 3, 4, 1, 2))
+	assert.Equal(t, "gpgeez: packet 1 of type 14 is out of order", err.Error())
+	err = checkPacketOrder(serialize(0, 1, 2, 3, 1, 2, 4))
+	assert.Equal(t, "gpgeez: packet 4 of type 13 is out of order", err.Error())
+	err = checkPacketOrder(serialize(1, 0, 2, 3, 4))
+	assert.Equal(t, "gpgeez: packet 0 of type 13 is out of order", err.Error())
+	err = checkPacketOrder(serialize(0, 1, 2, 3))
+	assert.Equal(t, "gpgeez: last subkey has no binding signature", err.Error())
+	err = checkPacketOrder(serialize(0, 2))
+	assert.Equal(t, "gpgeez: no user id", err.Error())
+}
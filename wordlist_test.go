@@ -0,0 +1,47 @@
+package gpgeez
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprintWordList(t *testing.T) {
+	// The example from https://en.wikipedia.org/wiki/PGP_word_list
+	fingerprint, err := hex.DecodeString("E58294F2E9A227486E8B061B31CC528FD7FA3F19")
+	assert.Nil(t, err)
+	expected := "topmost Istanbul Pluto vagabond treadmill Pacific brackish dictator goldfish Medusa " +
+		"afflict bravado chatter revolver Dupont midsummer stopwatch whimsical cowbell bottomless"
+	assert.Equal(t, expected, strings.Join(pgpWords(fingerprint), " "))
+
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	words := key.FingerprintWordList()
+	assert.Equal(t, 20, len(words))
+	assert.Equal(t, pgpWordsEven[key.PrimaryKey.Fingerprint[0]], words[0])
+	assert.Equal(t, pgpWordsOdd[key.PrimaryKey.Fingerprint[1]], words[1])
+}
+
+func TestFingerprintQR(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	qr, err := key.FingerprintQR()
+	assert.Nil(t, err, "FingerprintQR errored")
+
+	// A version 3 symbol is 29 modules wide, plus the quiet zone.
+	lines := strings.Split(strings.TrimSuffix(qr, "\n"), "\n")
+	assert.Equal(t, 19, len(lines))
+	for _, line := range lines {
+		assert.Equal(t, 37, len([]rune(line)))
+	}
+	assert.Equal(t, strings.Repeat(" ", 37), lines[0])
+	assert.Equal(t, "    █▀▀▀▀▀█", string([]rune(lines[2])[:11]))
+
+	_, err = (*Key)(nil).FingerprintQR()
+	assert.Equal(t, ErrNilKey, err)
+}
@@ -0,0 +1,192 @@
+package gpgeez
+
+import (
+	"time"
+
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// TrustPath returns the certification paths from from to to in kr. Each path
+// starts with from and ends with to, and each key in a path has certified a
+// user id of the next one. Only paths of minDepth to maxDepth hops are
+// returned; a direct certification of to by from is one hop. from and to don't
+// need to be in kr.
+//
+// Paths don't go through the same key twice, but the number of paths can
+// still grow exponentially with maxDepth, so keep it small.
+func (kr *KeyRing) TrustPath(from, to *Key, minDepth, maxDepth int) [][]*Key {
+	now := time.Now()
+	keys := kr.Keys()
+	var paths [][]*Key
+	var walk func(path []*Key)
+	walk = func(path []*Key) {
+		last := path[len(path)-1]
+		hops := len(path) - 1
+		if hops >= maxDepth {
+			return
+		}
+		if certifies(last, to, now) && hops+1 >= minDepth {
+			p := make([]*Key, len(path), len(path)+1)
+			copy(p, path)
+			paths = append(paths, append(p, to))
+		}
+		for _, k := range keys {
+			if k.Fingerprint() == to.Fingerprint() || inPath(path, k) {
+				continue
+			}
+			if certifies(last, k, now) {
+				walk(append(path, k))
+			}
+		}
+	}
+	walk([]*Key{from})
+	return paths
+}
+
+// maxCertDepth is how far ComputeValidity looks from the ultimately trusted
+// keys, like gpg's --max-cert-depth.
+const maxCertDepth = 5
+
+// ComputeValidity returns the validity of target, following GnuPG's classic
+// trust model. Keys in ultimateTrusted are TrustUltimate. Other keys are
+// TrustFull if they are certified by one fully valid key whose owner is
+// trusted fully or ultimately (see SetTrust), or by kr.MarginalsNeeded fully
+// valid keys whose owners are trusted marginally. Keys with fewer marginal
+// certifications are TrustMarginal, and the others TrustUnknown.
+//
+// Like GnuPG, validity is computed level by level from the ultimately trusted
+// keys, up to maxCertDepth levels, and each certification is verified at most
+// once.
+func (kr *KeyRing) ComputeValidity(target *Key, ultimateTrusted []*Key) TrustLevel {
+	if inPath(ultimateTrusted, target) {
+		return TrustUltimate
+	}
+	now := time.Now()
+	keys := append(kr.Keys(), target)
+	marginalsNeeded := kr.MarginalsNeeded
+	if marginalsNeeded == 0 {
+		marginalsNeeded = 3
+	}
+
+	verified := make(map[[2]string]bool)
+	certified := func(signer, key *Key) bool {
+		pair := [2]string{signer.Fingerprint(), key.Fingerprint()}
+		r, ok := verified[pair]
+		if !ok {
+			r = certifies(signer, key, now)
+			verified[pair] = r
+		}
+		return r
+	}
+
+	// introducers are the fully valid keys whose owners are trusted, along
+	// with their owner trust.
+	var introducers []*Key
+	trust := make(map[string]TrustLevel)
+	valid := make(map[string]bool)
+	for _, key := range ultimateTrusted {
+		introducers = append(introducers, key)
+		trust[key.Fingerprint()] = TrustUltimate
+		valid[key.Fingerprint()] = true
+	}
+	tally := func(key *Key) (full bool, marginals int) {
+		for _, introducer := range introducers {
+			if !certified(introducer, key) {
+				continue
+			}
+			if trust[introducer.Fingerprint()] >= TrustFull {
+				return true, marginals
+			}
+			marginals++
+		}
+		return marginals >= marginalsNeeded, marginals
+	}
+
+	level := introducers
+	for depth := 0; depth < maxCertDepth && len(level) > 0; depth++ {
+		level = nil
+		for _, key := range keys {
+			fp := key.Fingerprint()
+			if valid[fp] {
+				continue
+			}
+			if full, _ := tally(key); !full {
+				continue
+			}
+			if fp == target.Fingerprint() {
+				return TrustFull
+			}
+			valid[fp] = true
+			if t := key.Trust(); t >= TrustMarginal {
+				trust[fp] = t
+				level = append(level, key)
+			}
+		}
+		// The keys validated at this level only introduce keys at the next
+		// one, if there is one.
+		if depth+1 < maxCertDepth {
+			introducers = append(introducers, level...)
+		}
+	}
+
+	if _, marginals := tally(target); marginals > 0 {
+		return TrustMarginal
+	}
+	return TrustUnknown
+}
+
+func inPath(path []*Key, key *Key) bool {
+	for _, k := range path {
+		if k.Fingerprint() == key.Fingerprint() {
+			return true
+		}
+	}
+	return false
+}
+
+// certifies returns true if signer has a valid certification on one of the
+// user ids of key, which hasn't been revoked.
+func certifies(signer, key *Key, now time.Time) bool {
+	if signer.Fingerprint() == key.Fingerprint() {
+		return false
+	}
+	for _, id := range key.Identities {
+		for _, sig := range id.Signatures {
+			if !issuedBy(sig, signer) || !isCertification(sig.SigType) || sigExpired(sig, now) {
+				continue
+			}
+			if signer.PrimaryKey.VerifyUserIdSignature(id.UserId.Id, key.PrimaryKey, sig) != nil {
+				continue
+			}
+			if !certificationRevoked(signer, key, id.UserId.Id, id.Signatures, sig.CreationTime) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// certificationRevoked returns true if sigs contains a valid revocation by
+// signer, made after a certification created at t.
+func certificationRevoked(signer, key *Key, uid string, sigs []*packet.Signature, t time.Time) bool {
+	for _, sig := range sigs {
+		if sig.SigType == sigTypeCertificationRevocation && issuedBy(sig, signer) &&
+			!sig.CreationTime.Before(t) &&
+			signer.PrimaryKey.VerifyUserIdSignature(uid, key.PrimaryKey, sig) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func issuedBy(sig *packet.Signature, signer *Key) bool {
+	return sig.IssuerKeyId != nil && *sig.IssuerKeyId == signer.PrimaryKey.KeyId
+}
+
+func isCertification(t packet.SignatureType) bool {
+	switch t {
+	case packet.SigTypeGenericCert, packet.SigTypePersonaCert, packet.SigTypeCasualCert, packet.SigTypePositiveCert:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package gpgeez
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// certify makes signer certify the primary identity of key.
+func certify(t *testing.T, key, signer *Key, config *Config) {
+	err := key.SignIdentity(key.primaryIdentity().UserId.Id, &signer.Entity, config.PacketConfig())
+	assert.Nil(t, err, "SignIdentity errored")
+}
+
+func TestTrustPath(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	var keys []*Key
+	for _, name := range []string{"Alice", "Bob", "Carol", "Dave"} {
+		key, err := CreateKey(name, "", name+"@example.com", &config)
+		assert.Nil(t, err, "CreateKey errored")
+		keys = append(keys, key)
+	}
+	alice, bob, carol, dave := keys[0], keys[1], keys[2], keys[3]
+	certify(t, bob, alice, &config)
+	certify(t, carol, bob, &config)
+	certify(t, carol, alice, &config)
+	certify(t, alice, carol, &config)
+	kr := NewKeyRing(keys...)
+
+	paths := kr.TrustPath(alice, carol, 1, 3)
+	assert.Equal(t, 2, len(paths))
+	assert.Equal(t, []*Key{alice, carol}, paths[0])
+	assert.Equal(t, []*Key{alice, bob, carol}, paths[1])
+
+	assert.Equal(t, [][]*Key{{alice, bob, carol}}, kr.TrustPath(alice, carol, 2, 3))
+	assert.Equal(t, [][]*Key{{alice, carol}}, kr.TrustPath(alice, carol, 1, 1))
+	assert.Equal(t, 0, len(kr.TrustPath(alice, dave, 1, 3)))
+	assert.Equal(t, 0, len(kr.TrustPath(bob, alice, 1, 1)))
+	assert.Equal(t, [][]*Key{{bob, carol, alice}}, kr.TrustPath(bob, alice, 1, 3))
+}
+
+func TestComputeValidity(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	var keys []*Key
+	for _, name := range []string{"Alice", "Bob", "Carol", "Dave"} {
+		key, err := CreateKey(name, "", name+"@example.com", &config)
+		assert.Nil(t, err, "CreateKey errored")
+		keys = append(keys, key)
+	}
+	alice, bob, carol, dave := keys[0], keys[1], keys[2], keys[3]
+	kr := NewKeyRing(bob, carol, dave)
+	ultimate := []*Key{alice}
+
+	assert.Equal(t, TrustUltimate, kr.ComputeValidity(alice, ultimate))
+	assert.Equal(t, TrustUnknown, kr.ComputeValidity(bob, ultimate))
+
+	certify(t, bob, alice, &config)
+	certify(t, dave, alice, &config)
+	assert.Equal(t, TrustFull, kr.ComputeValidity(bob, ultimate))
+
+	// Bob and Dave are valid, but their certifications only count once their
+	// owners are trusted.
+	certify(t, carol, bob, &config)
+	certify(t, carol, dave, &config)
+	assert.Equal(t, TrustUnknown, kr.ComputeValidity(carol, ultimate))
+	bob.SetTrust(TrustMarginal)
+	assert.Equal(t, TrustMarginal, kr.ComputeValidity(carol, ultimate))
+	dave.SetTrust(TrustMarginal)
+	assert.Equal(t, TrustMarginal, kr.ComputeValidity(carol, ultimate))
+	kr.MarginalsNeeded = 2
+	assert.Equal(t, TrustFull, kr.ComputeValidity(carol, ultimate))
+	kr.MarginalsNeeded = 0
+	bob.SetTrust(TrustFull)
+	assert.Equal(t, TrustFull, kr.ComputeValidity(carol, ultimate))
+
+	// Without a path from an ultimately trusted key, nothing is valid.
+	assert.Equal(t, TrustUnknown, kr.ComputeValidity(carol, nil))
+}
+
+func TestComputeValidityDepth(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour, Curve: "P-256"}
+	root, err := CreateKey("Root", "", "root@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+	kr := NewKeyRing()
+	chain := []*Key{root}
+	for i := 0; i <= maxCertDepth; i++ {
+		key, err := CreateKey("Chain", "", "chain@example.com", &config)
+		assert.Nil(t, err, "CreateKey errored")
+		certify(t, key, chain[i], &config)
+		key.SetTrust(TrustFull)
+		kr.Add(key)
+		chain = append(chain, key)
+	}
+	// A cycle back to the start of the chain doesn't change anything.
+	certify(t, chain[1], chain[maxCertDepth], &config)
+
+	ultimate := []*Key{root}
+	for i := 1; i <= maxCertDepth; i++ {
+		assert.Equal(t, TrustFull, kr.ComputeValidity(chain[i], ultimate), "key at depth %d", i)
+	}
+	assert.Equal(t, TrustUnknown, kr.ComputeValidity(chain[maxCertDepth+1], ultimate))
+}
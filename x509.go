@@ -0,0 +1,61 @@
+package gpgeez
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+)
+
+// ImportPKCS8PrivateKey creates a key whose primary key is the PKCS#8 private
+// key in pemBlock (a "PRIVATE KEY" PEM block), with a fresh self-signed
+// identity and a new encryption subkey generated according to config. This
+// lets an existing TLS key double as an OpenPGP signing key.
+//
+// RSA keys and ECDSA keys on the NIST curves are supported.
+func ImportPKCS8PrivateKey(pemBlock []byte, name, comment, email string, config *Config) (*Key, error) {
+	block, _ := pem.Decode(pemBlock)
+	if block == nil {
+		return nil, errors.New("gpgeez: no PEM block found")
+	}
+	if block.Type != "PRIVATE KEY" {
+		return nil, errors.New("gpgeez: unexpected PEM block type " + block.Type)
+	}
+	priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return importPrivateKey(priv, name, comment, email, config)
+}
+
+// SelfSignedCert creates an X.509 certificate from template, self-signed with
+// the primary private key. The certificate's Subject Key Identifier is set to
+// the key's OpenPGP fingerprint, which makes it easy to tie both together.
+//
+// template is not modified. The certificate is returned both parsed and in
+// DER form.
+func (key *Key) SelfSignedCert(template *x509.Certificate) (*x509.Certificate, []byte, error) {
+	if key.PrivateKey == nil {
+		return nil, nil, errors.New("gpgeez: key has no private material")
+	}
+	if key.PrivateKey.Encrypted {
+		return nil, nil, errors.New("gpgeez: private key must be decrypted")
+	}
+	signer, ok := key.PrivateKey.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, nil, errors.New("gpgeez: unsupported key algorithm for X.509 certificates")
+	}
+
+	t := *template
+	t.SubjectKeyId = key.PrimaryKey.Fingerprint[:]
+	der, err := x509.CreateCertificate(rand.Reader, &t, &t, signer.Public(), signer)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, der, nil
+}
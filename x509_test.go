@@ -0,0 +1,46 @@
+package gpgeez
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportPKCS8PrivateKey(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	assert.Nil(t, err)
+	pemBlock := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	config := Config{Expiry: 365 * 24 * time.Hour}
+	key, err := ImportPKCS8PrivateKey(pemBlock, "Joe", "tls key", "joe@example.com", &config)
+	assert.Nil(t, err, "ImportPKCS8PrivateKey errored")
+	assert.Equal(t, &priv.PublicKey, key.PrimaryKey.PublicKey)
+}
+
+func TestSelfSignedCert(t *testing.T) {
+	config := Config{Expiry: 365 * 24 * time.Hour}
+	key, err := CreateKey("Joe", "test key", "joe@example.com", &config)
+	assert.Nil(t, err, "CreateKey errored")
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "joe.example.com"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	cert, der, err := key.SelfSignedCert(template)
+	assert.Nil(t, err, "SelfSignedCert errored")
+	assert.NotEmpty(t, der)
+	assert.Equal(t, key.PrimaryKey.Fingerprint[:], cert.SubjectKeyId)
+	assert.Nil(t, cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature))
+}